@@ -0,0 +1,131 @@
+package saferand
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	errShuffleBias = errors.New("saferand: shuffle permutations are not uniform")
+	errShuffleSwap = errors.New("saferand: shuffle swapped an index out of range")
+)
+
+// ShuffleAudited shuffles n elements using the Fisher-Yates
+// algorithm.
+//
+// It panics if n < 0.
+func ShuffleAudited(n int, swap func(i, j int)) { defaultRand.ShuffleAudited(n, swap) }
+
+// ShuffleAudited shuffles n elements using the Fisher-Yates
+// algorithm.
+//
+// It is written to be easy to audit. For each i from n-1 down
+// to 1, it calls swap(i, j) exactly once, with j drawn
+// uniformly from [0, i] by Uint64n. Uint64n uses rejection
+// sampling, so j is unbiased for every i, including i >
+// math.MaxInt32. Since j ranges over [0, i] rather than
+// [0, i), each of the n! permutations is produced by exactly
+// one sequence of draws and is equally likely. AuditShuffle
+// checks this empirically.
+//
+// It panics if n < 0.
+func (r *Rand) ShuffleAudited(n int, swap func(i, j int)) {
+	if n < 0 {
+		panic("invalid argument to ShuffleAudited")
+	}
+	for i := n - 1; i > 0; i-- {
+		// i+1 <= math.MaxInt, so the conversion is exact.
+		j := int(r.Uint64n(uint64(i) + 1))
+		swap(i, j)
+	}
+}
+
+// maxAuditN is the largest n accepted by AuditShuffle. 8! is
+// 40320 permutations.
+const maxAuditN = 8
+
+// AuditShuffle checks that shuffle produces every permutation
+// of n elements with equal probability.
+//
+// It shuffles n elements trials times, counts how often each of
+// the n! permutations occurs, and runs a chi-square test. It
+// returns an error if the counts deviate from uniform at a
+// significance level of 0.0001, so a correct shuffle fails about
+// once in 10,000 runs. It also returns an error if shuffle
+// swaps an index outside of [0, n).
+//
+// shuffle has the same signature as Shuffle and
+// ShuffleAudited, so it can audit either, or a shuffle from
+// another package.
+//
+// It panics unless 2 <= n <= 8 and trials >= 5*n!, the usual
+// minimum for the chi-square test to be accurate.
+func AuditShuffle(shuffle func(n int, swap func(i, j int)), n, trials int) error {
+	if n < 2 || n > maxAuditN {
+		panic("invalid argument to AuditShuffle")
+	}
+	perms := 1
+	for i := 2; i <= n; i++ {
+		perms *= i
+	}
+	if trials < 5*perms {
+		panic("invalid argument to AuditShuffle")
+	}
+
+	counts := make([]int, perms)
+	var p [maxAuditN]int
+	for t := 0; t < trials; t++ {
+		for i := range p[:n] {
+			p[i] = i
+		}
+		ok := true
+		shuffle(n, func(i, j int) {
+			if i < 0 || i >= n || j < 0 || j >= n {
+				ok = false
+				return
+			}
+			p[i], p[j] = p[j], p[i]
+		})
+		if !ok {
+			return errShuffleSwap
+		}
+		counts[permRank(p[:n])]++
+	}
+
+	want := float64(trials) / float64(perms)
+	var x2 float64
+	for _, c := range counts {
+		d := float64(c) - want
+		x2 += d * d / want
+	}
+	if x2 > chiSquareCritical(perms-1) {
+		return errShuffleBias
+	}
+	return nil
+}
+
+// permRank returns the rank of the permutation p of [0, len(p))
+// in lexicographic order, using its Lehmer code.
+func permRank(p []int) int {
+	rank := 0
+	for i := range p {
+		smaller := 0
+		for _, x := range p[i+1:] {
+			if x < p[i] {
+				smaller++
+			}
+		}
+		rank = rank*(len(p)-i) + smaller
+	}
+	return rank
+}
+
+// chiSquareCritical approximates the chi-square critical value
+// at p = 0.0001 for df degrees of freedom using the
+// Wilson-Hilferty transformation.
+func chiSquareCritical(df int) float64 {
+	const z = 3.719 // z-score for p = 0.0001
+	k := float64(df)
+	h := 2 / (9 * k)
+	return k * math.Pow(1-h+z*math.Sqrt(h), 3)
+}
@@ -0,0 +1,112 @@
+package saferand
+
+import (
+	"testing"
+)
+
+func TestPermRank(t *testing.T) {
+	// Every permutation of 4 elements has a distinct rank in
+	// [0, 24).
+	seen := make([]bool, 24)
+	var p [4]int
+	var gen func(k int, used int)
+	gen = func(k int, used int) {
+		if k == len(p) {
+			r := permRank(p[:])
+			if r < 0 || r >= len(seen) || seen[r] {
+				t.Fatalf("%v: bad rank %d", p, r)
+			}
+			seen[r] = true
+			return
+		}
+		for x := 0; x < len(p); x++ {
+			if used&(1<<x) == 0 {
+				p[k] = x
+				gen(k+1, used|1<<x)
+			}
+		}
+	}
+	gen(0, 0)
+	if got := permRank([]int{0, 1, 2, 3}); got != 0 {
+		t.Fatalf("identity: got rank %d", got)
+	}
+	if got := permRank([]int{3, 2, 1, 0}); got != 23 {
+		t.Fatalf("reverse: got rank %d", got)
+	}
+}
+
+func factorial(n int) int {
+	f := 1
+	for i := 2; i <= n; i++ {
+		f *= i
+	}
+	return f
+}
+
+func TestShuffleAudited(t *testing.T) {
+	for n := 2; n <= 6; n++ {
+		trials := 20 * factorial(n)
+		if trials < 10000 {
+			trials = 10000
+		}
+		if err := AuditShuffle(ShuffleAudited, n, trials); err != nil {
+			t.Fatalf("ShuffleAudited(%d): %v", n, err)
+		}
+		if err := AuditShuffle(Shuffle, n, trials); err != nil {
+			t.Fatalf("Shuffle(%d): %v", n, err)
+		}
+	}
+
+	// Each i from n-1 down to 1 is swapped exactly once, with
+	// j in [0, i].
+	next := 9
+	ShuffleAudited(10, func(i, j int) {
+		if i != next || j < 0 || j > i {
+			t.Fatalf("swap(%d, %d), expected i = %d", i, j, next)
+		}
+		next--
+	})
+	if next != 0 {
+		t.Fatalf("stopped at i = %d", next)
+	}
+	ShuffleAudited(0, func(i, j int) { t.Fatal("swap called for n = 0") })
+	ShuffleAudited(1, func(i, j int) { t.Fatal("swap called for n = 1") })
+
+	checkPanics(t, "ShuffleAudited(-1)", func() { ShuffleAudited(-1, func(i, j int) {}) })
+}
+
+func TestAuditShuffleDetectsBias(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		shuffle func(n int, swap func(i, j int))
+	}{
+		// Drawing j from [0, n) at every step produces n^n
+		// equally likely sequences, which n! does not divide.
+		{"naive", func(n int, swap func(i, j int)) {
+			for i := n - 1; i > 0; i-- {
+				swap(i, Intn(n))
+			}
+		}},
+		// Drawing j from [0, i) is Sattolo's algorithm, which
+		// only produces cyclic permutations.
+		{"off by one", func(n int, swap func(i, j int)) {
+			for i := n - 1; i > 0; i-- {
+				swap(i, Intn(i))
+			}
+		}},
+		{"identity", func(n int, swap func(i, j int)) {}},
+	} {
+		if err := AuditShuffle(tc.shuffle, 4, 24000); err != errShuffleBias {
+			t.Errorf("%s: expected %v, got %v", tc.name, errShuffleBias, err)
+		}
+	}
+
+	err := AuditShuffle(func(n int, swap func(i, j int)) { swap(0, n) }, 3, 30)
+	if err != errShuffleSwap {
+		t.Errorf("expected %v, got %v", errShuffleSwap, err)
+	}
+
+	checkPanics(t, "AuditShuffle(n = 1)", func() { AuditShuffle(Shuffle, 1, 100) })
+	checkPanics(t, "AuditShuffle(n = 9)", func() { AuditShuffle(Shuffle, 9, 1e7) })
+	checkPanics(t, "AuditShuffle(too few trials)", func() { AuditShuffle(Shuffle, 4, 100) })
+}
@@ -0,0 +1,47 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// errNonPositiveMax is returned by BigInt when max <= 0.
+var errNonPositiveMax = errors.New("saferand: max must be positive")
+
+// BigInt returns a uniform random value in [0, max).
+//
+// It returns an error if max <= 0.
+func BigInt(max *big.Int) (*big.Int, error) { return defaultRand.BigInt(max) }
+
+// MustBigInt is like BigInt, but panics if BigInt returns an
+// error.
+func MustBigInt(max *big.Int) *big.Int {
+	v, err := BigInt(max)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// BigInt returns a uniform random value in [0, max).
+//
+// It returns an error if max <= 0.
+func (r *Rand) BigInt(max *big.Int) (*big.Int, error) {
+	if max.Sign() <= 0 {
+		return nil, errNonPositiveMax
+	}
+	return rand.Int(r, max)
+}
+
+// Prime returns a random prime of exactly bits bits.
+//
+// It returns an error if bits < 2.
+func Prime(bits int) (*big.Int, error) { return defaultRand.Prime(bits) }
+
+// Prime returns a random prime of exactly bits bits.
+//
+// It returns an error if bits < 2.
+func (r *Rand) Prime(bits int) (*big.Int, error) {
+	return rand.Prime(r, bits)
+}
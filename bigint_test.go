@@ -0,0 +1,59 @@
+package saferand
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBigInt(t *testing.T) {
+	max := new(big.Int).Lsh(big.NewInt(1), 200)
+	max.Sub(max, big.NewInt(12345))
+	for i := 0; i < 1000; i++ {
+		v, err := BigInt(max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Sign() < 0 || v.Cmp(max) >= 0 {
+			t.Fatalf("%s out of range", v)
+		}
+	}
+
+	counts := make([]int, 7)
+	for i := 0; i < 70000; i++ {
+		counts[MustBigInt(big.NewInt(7)).Int64()]++
+	}
+	checkUniform(t, counts)
+}
+
+func TestBigIntNonPositive(t *testing.T) {
+	for _, max := range []*big.Int{big.NewInt(0), big.NewInt(-1)} {
+		if _, err := BigInt(max); err == nil {
+			t.Fatalf("BigInt(%s): expected an error", max)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	MustBigInt(big.NewInt(0))
+}
+
+func TestPrime(t *testing.T) {
+	p, err := Prime(256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.ProbablyPrime(20) {
+		t.Fatalf("%s is not prime", p)
+	}
+	if n := p.BitLen(); n != 256 {
+		t.Fatalf("expected 256 bits, got %d", n)
+	}
+
+	for _, bits := range []int{-1, 0, 1} {
+		if _, err := Prime(bits); err == nil {
+			t.Fatalf("Prime(%d): expected an error", bits)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// defaultBufferSize is the number of bytes read at once by
+// buffered sources when no size is provided.
+const defaultBufferSize = 4096
+
+// BufferedSource is a Source that amortizes the cost of
+// crypto/rand by reading large blocks at once.
+//
+// It is safe for concurrent use by multiple goroutines.
+type BufferedSource struct {
+	mu sync.Mutex
+	// buf[off:] has not yet been handed out. buf[:off] has
+	// been handed out and zeroed.
+	buf []byte
+	off int
+	// pid is the process ID when buf was last filled.
+	pid int
+}
+
+var _ Source = (*BufferedSource)(nil)
+
+// NewBufferedSource returns a BufferedSource that reads size
+// bytes at a time from crypto/rand.
+//
+// If size <= 0, a default of 4 KiB is used. Otherwise, size is
+// rounded up to a multiple of eight.
+//
+// If the process forks, the child discards any buffered bytes
+// before generating more output. ForceReseed does the same
+// thing on demand.
+func NewBufferedSource(size int) *BufferedSource {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	size = (size + 7) &^ 7
+	return &BufferedSource{
+		buf: make([]byte, size),
+		off: size,
+	}
+}
+
+func (*BufferedSource) Seed(_ uint64) {}
+
+// ForceReseed discards any buffered bytes so that the next
+// value is read from crypto/rand.
+func (s *BufferedSource) ForceReseed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wipe(s.buf[s.off:])
+	s.off = len(s.buf)
+}
+
+func (s *BufferedSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *BufferedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.off == len(s.buf) || s.pid != getpid() {
+		s.fill()
+	}
+	b := s.buf[s.off : s.off+8]
+	x := binary.LittleEndian.Uint64(b)
+	wipe(b)
+	s.off += 8
+	return x
+}
+
+// fill refills the buffer from crypto/rand.
+//
+// s.mu must be held.
+func (s *BufferedSource) fill() {
+	if _, err := rand.Read(s.buf); err != nil {
+		panic(err)
+	}
+	s.off = 0
+	s.pid = getpid()
+}
+
+// bufferedReader is an io.Reader that serves small reads from
+// a block read from crypto/rand.
+type bufferedReader struct {
+	mu sync.Mutex
+	r  io.Reader
+	// buf[off:] has not yet been handed out. buf[:off] has
+	// been handed out and zeroed.
+	buf []byte
+	off int
+	// pid is the process ID when buf was last filled.
+	pid int
+}
+
+var (
+	_ io.Reader     = (*bufferedReader)(nil)
+	_ io.ByteReader = (*bufferedReader)(nil)
+)
+
+// NewBufferedReader returns a cryptographically secure
+// io.Reader that reads size bytes at a time from crypto/rand
+// and serves smaller reads from the remainder.
+//
+// If size <= 0, a default of 4 KiB is used. Reads of at least
+// size bytes bypass the buffer.
+//
+// Bytes are zeroed once they have been handed out, and the
+// buffer is discarded if the process forks.
+//
+// The returned io.Reader also implements io.ByteReader. It is
+// safe for concurrent use by multiple goroutines.
+func NewBufferedReader(size int) io.Reader {
+	return newBufferedReader(rand.Reader, size)
+}
+
+func newBufferedReader(r io.Reader, size int) *bufferedReader {
+	if size <= 0 {
+		size = defaultBufferSize
+	}
+	return &bufferedReader{
+		r:   r,
+		buf: make([]byte, size),
+		off: size,
+	}
+}
+
+// Read fills p with random bytes.
+//
+// It always returns len(p) and a nil error, or fewer than
+// len(p) bytes and a non-nil error.
+func (b *bufferedReader) Read(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkFork()
+	n := 0
+	for n < len(p) {
+		if b.off < len(b.buf) {
+			m := copy(p[n:], b.buf[b.off:])
+			wipe(b.buf[b.off : b.off+m])
+			b.off += m
+			n += m
+			continue
+		}
+		if len(p)-n >= len(b.buf) {
+			// Large reads gain nothing from the buffer.
+			m, err := io.ReadFull(b.r, p[n:])
+			n += m
+			if err != nil {
+				return n, err
+			}
+			continue
+		}
+		if err := b.fill(); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// ReadByte returns a single random byte.
+//
+// Like Read, it only reads from crypto/rand when the buffer is
+// empty. It never returns io.EOF.
+func (b *bufferedReader) ReadByte() (byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkFork()
+	if b.off == len(b.buf) {
+		if err := b.fill(); err != nil {
+			return 0, err
+		}
+	}
+	c := b.buf[b.off]
+	b.buf[b.off] = 0
+	b.off++
+	return c, nil
+}
+
+// checkFork discards the buffer if the process has forked
+// since it was filled.
+//
+// b.mu must be held.
+func (b *bufferedReader) checkFork() {
+	if b.pid != getpid() {
+		wipe(b.buf[b.off:])
+		b.off = len(b.buf)
+	}
+}
+
+// fill refills the buffer.
+//
+// b.mu must be held.
+func (b *bufferedReader) fill() error {
+	if _, err := io.ReadFull(b.r, b.buf); err != nil {
+		wipe(b.buf)
+		return err
+	}
+	b.off = 0
+	b.pid = getpid()
+	return nil
+}
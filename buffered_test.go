@@ -0,0 +1,269 @@
+package saferand
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestBufferedSourceSize(t *testing.T) {
+	for _, tc := range []struct {
+		size, want int
+	}{
+		{-1, defaultBufferSize},
+		{0, defaultBufferSize},
+		{1, 8},
+		{8, 8},
+		{9, 16},
+		{1000, 1000},
+	} {
+		src := NewBufferedSource(tc.size)
+		if got := len(src.buf); got != tc.want {
+			t.Errorf("NewBufferedSource(%d): got %d bytes, expected %d",
+				tc.size, got, tc.want)
+		}
+	}
+}
+
+// TestBufferedSourceUniform checks the output of a small
+// buffered source, which forces many refills.
+func TestBufferedSourceUniform(t *testing.T) {
+	src := NewBufferedSource(24)
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x := src.Uint64()
+		hi[x>>60]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+}
+
+func TestBufferedSourceZeroes(t *testing.T) {
+	src := NewBufferedSource(64)
+	for i := 0; i < 13; i++ {
+		src.Uint64()
+		for j, c := range src.buf[:src.off] {
+			if c != 0 {
+				t.Fatalf("#%d: consumed byte %d not zeroed: %#x", i, j, c)
+			}
+		}
+	}
+}
+
+func TestBufferedSourceInt63(t *testing.T) {
+	src := NewBufferedSource(16)
+	for i := 0; i < 1000; i++ {
+		if x := src.Int63(); x < 0 {
+			t.Fatalf("#%d: Int63 returned negative value %d", i, x)
+		}
+	}
+}
+
+func TestBufferedSourceConcurrent(t *testing.T) {
+	src := NewBufferedSource(32)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				src.Uint64()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkBufferedSource(b *testing.B) {
+	src := NewBufferedSource(0)
+	for n := b.N; n > 0; n-- {
+		src.Uint64()
+	}
+}
+
+// countReader is an io.Reader that returns the bytes 0, 1, 2,
+// ... and records the size of each read.
+type countReader struct {
+	next  byte
+	reads []int
+}
+
+func (r *countReader) Read(p []byte) (int, error) {
+	r.reads = append(r.reads, len(p))
+	for i := range p {
+		p[i] = r.next
+		r.next++
+	}
+	return len(p), nil
+}
+
+func TestBufferedReaderRefill(t *testing.T) {
+	cr := &countReader{}
+	br := newBufferedReader(cr, 16)
+
+	// Reads of 5 bytes straddle the 16-byte refill boundary.
+	// The output must be exactly the underlying stream: no
+	// byte repeated and none skipped.
+	var want byte
+	for i := 0; i < 20; i++ {
+		p := make([]byte, 5)
+		if n, err := br.Read(p); n != len(p) || err != nil {
+			t.Fatalf("#%d: Read returned (%d, %v)", i, n, err)
+		}
+		for j, c := range p {
+			if c != want {
+				t.Fatalf("#%d: byte %d: got %d, expected %d", i, j, c, want)
+			}
+			want++
+		}
+		for j, c := range br.buf[:br.off] {
+			if c != 0 {
+				t.Fatalf("#%d: consumed byte %d not zeroed: %#x", i, j, c)
+			}
+		}
+	}
+	for i, n := range cr.reads {
+		if n != 16 {
+			t.Fatalf("read #%d: got %d bytes, expected 16", i, n)
+		}
+	}
+	if got, exp := len(cr.reads), (20*5+15)/16; got != exp {
+		t.Fatalf("got %d underlying reads, expected %d", got, exp)
+	}
+}
+
+func TestBufferedReaderBypass(t *testing.T) {
+	cr := &countReader{}
+	br := newBufferedReader(cr, 16)
+
+	// Leave 13 bytes buffered.
+	br.Read(make([]byte, 3))
+
+	// A large read drains the buffer, then reads the rest
+	// directly into p.
+	p := make([]byte, 100)
+	if n, err := br.Read(p); n != len(p) || err != nil {
+		t.Fatalf("Read returned (%d, %v)", n, err)
+	}
+	for i, c := range p {
+		if c != byte(3+i) {
+			t.Fatalf("byte %d: got %d, expected %d", i, c, 3+i)
+		}
+	}
+	if got, exp := cr.reads, []int{16, 100 - 13}; len(got) != 2 || got[0] != exp[0] || got[1] != exp[1] {
+		t.Fatalf("got underlying reads %v, expected %v", got, exp)
+	}
+	if br.off != len(br.buf) {
+		t.Fatalf("buffer should be empty: off = %d", br.off)
+	}
+}
+
+func TestBufferedReaderError(t *testing.T) {
+	br := newBufferedReader(iotest.ErrReader(io.ErrUnexpectedEOF), 16)
+	for _, size := range []int{4, 64} {
+		n, err := br.Read(make([]byte, size))
+		if n != 0 || err != io.ErrUnexpectedEOF {
+			t.Fatalf("Read(%d bytes) returned (%d, %v), expected %v",
+				size, n, err, io.ErrUnexpectedEOF)
+		}
+	}
+}
+
+func TestBufferedReaderConcurrent(t *testing.T) {
+	r := NewBufferedReader(32)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := make([]byte, 7)
+			for j := 0; j < 1000; j++ {
+				if _, err := r.Read(p); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkBufferedReader(b *testing.B) {
+	r := NewBufferedReader(0)
+	p := make([]byte, 8)
+	b.SetBytes(int64(len(p)))
+	for n := b.N; n > 0; n-- {
+		r.Read(p)
+	}
+}
+
+func TestBufferedReaderReadByte(t *testing.T) {
+	cr := &countReader{}
+	br := newBufferedReader(cr, 16)
+
+	// Interleave ReadByte and Read across several refills.
+	var want byte
+	for i := 0; i < 50; i++ {
+		c, err := br.ReadByte()
+		if err != nil {
+			t.Fatalf("#%d: ReadByte: %v", i, err)
+		}
+		if c != want {
+			t.Fatalf("#%d: ReadByte: got %d, expected %d", i, c, want)
+		}
+		want++
+		if i%7 == 0 {
+			p := make([]byte, 3)
+			br.Read(p)
+			for _, c := range p {
+				if c != want {
+					t.Fatalf("#%d: Read: got %d, expected %d", i, c, want)
+				}
+				want++
+			}
+		}
+		for j, c := range br.buf[:br.off] {
+			if c != 0 {
+				t.Fatalf("#%d: consumed byte %d not zeroed: %#x", i, j, c)
+			}
+		}
+	}
+	for i, n := range cr.reads {
+		if n != 16 {
+			t.Fatalf("read #%d: got %d bytes, expected 16", i, n)
+		}
+	}
+
+	br = newBufferedReader(iotest.ErrReader(io.ErrUnexpectedEOF), 16)
+	if _, err := br.ReadByte(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+}
+
+func TestBufferedReaderByteReader(t *testing.T) {
+	br, ok := NewBufferedReader(64).(io.ByteReader)
+	if !ok {
+		t.Fatal("NewBufferedReader does not implement io.ByteReader")
+	}
+	var counts [16]int
+	same := 0
+	prev, _ := br.ReadByte()
+	for i := 0; i < 50000; i++ {
+		c, err := br.ReadByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c == prev {
+			same++
+		}
+		prev = c
+		counts[c>>4]++
+	}
+	// Successive bytes match about 1/256 of the time.
+	if same > 50000/256*2 {
+		t.Fatalf("%d successive bytes were equal", same)
+	}
+	checkUniform(t, counts[:])
+}
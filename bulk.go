@@ -0,0 +1,144 @@
+package saferand
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// maxBulkRead is the largest number of bytes the bulk
+// functions read at once.
+const maxBulkRead = 4096
+
+// Int63s fills dst with non-negative random 63-bit integers.
+func Int63s(dst []int64) { defaultRand.Int63s(dst) }
+
+// Int63s fills dst with non-negative random 63-bit integers.
+//
+// It is much faster than calling Int63 for each element since
+// it reads entropy in large blocks.
+func (r *Rand) Int63s(dst []int64) {
+	buf := bulkBuffer(len(dst))
+	for len(dst) > 0 {
+		n := len(dst)
+		if n > len(buf)/8 {
+			n = len(buf) / 8
+		}
+		r.fill(buf[:n*8])
+		for i := range dst[:n] {
+			x := binary.LittleEndian.Uint64(buf[i*8:])
+			dst[i] = int64(x &^ (1 << 63))
+		}
+		dst = dst[n:]
+	}
+	wipe(buf)
+}
+
+// Uint64s fills dst with random 64-bit integers.
+func Uint64s(dst []uint64) { defaultRand.Uint64s(dst) }
+
+// Uint64s fills dst with random 64-bit integers.
+//
+// It is much faster than calling Uint64 for each element since
+// it reads entropy in large blocks.
+func (r *Rand) Uint64s(dst []uint64) {
+	buf := bulkBuffer(len(dst))
+	for len(dst) > 0 {
+		n := len(dst)
+		if n > len(buf)/8 {
+			n = len(buf) / 8
+		}
+		r.fill(buf[:n*8])
+		for i := range dst[:n] {
+			dst[i] = binary.LittleEndian.Uint64(buf[i*8:])
+		}
+		dst = dst[n:]
+	}
+	wipe(buf)
+}
+
+// Float64s fills dst with uniform random numbers in [0, 1).
+func Float64s(dst []float64) { defaultRand.Float64s(dst) }
+
+// Float64s fills dst with uniform random numbers in [0, 1).
+//
+// Each element is built from the top 53 bits of a 64-bit word,
+// like Float64Pos but including zero and excluding one. It
+// reads entropy in large blocks, so it is much faster than
+// calling Float64 for each element, but returns different
+// values for the same Source.
+func (r *Rand) Float64s(dst []float64) {
+	buf := bulkBuffer(len(dst))
+	for len(dst) > 0 {
+		n := len(dst)
+		if n > len(buf)/8 {
+			n = len(buf) / 8
+		}
+		r.fill(buf[:n*8])
+		for i := range dst[:n] {
+			x := binary.LittleEndian.Uint64(buf[i*8:])
+			dst[i] = float64(x>>11) * 0x1p-53
+		}
+		dst = dst[n:]
+	}
+	wipe(buf)
+}
+
+// Records returns count slices of width random bytes each.
+//
+// It panics if count < 0, width < 0, or count*width overflows
+// an int.
+func Records(count, width int) [][]byte { return defaultRand.Records(count, width) }
+
+// Records returns count slices of width random bytes each.
+//
+// The records share a single backing array filled by one read,
+// instead of one read per record. Each record's capacity is
+// limited to width, so appending to one record never
+// overwrites the next.
+//
+// It panics if count < 0, width < 0, or count*width overflows
+// an int.
+func (r *Rand) Records(count, width int) [][]byte {
+	if count < 0 || width < 0 || (width > 0 && count > math.MaxInt/width) {
+		panic("invalid argument to Records")
+	}
+	buf := make([]byte, count*width)
+	r.fill(buf)
+	out := make([][]byte, count)
+	for i := range out {
+		out[i] = buf[i*width : (i+1)*width : (i+1)*width]
+	}
+	return out
+}
+
+// bulkBuffer returns a buffer large enough for n 64-bit words,
+// up to maxBulkRead bytes.
+func bulkBuffer(n int) []byte {
+	if n > maxBulkRead/8 {
+		n = maxBulkRead / 8
+	}
+	return make([]byte, n*8)
+}
+
+// Bits returns n random bits packed into 64-bit words.
+//
+// It panics if n < 0.
+func Bits(n int) []uint64 { return defaultRand.Bits(n) }
+
+// Bits returns n random bits packed into 64-bit words.
+//
+// Bit i is stored in bit i%64 of word i/64. Bits past n in
+// the last word are zero.
+//
+// It panics if n < 0.
+func (r *Rand) Bits(n int) []uint64 {
+	if n < 0 {
+		panic("invalid argument to Bits")
+	}
+	dst := make([]uint64, (n+63)/64)
+	r.Uint64s(dst)
+	if k := n % 64; k != 0 {
+		dst[len(dst)-1] &= 1<<k - 1
+	}
+	return dst
+}
@@ -0,0 +1,266 @@
+package saferand
+
+import (
+	"bytes"
+	"math"
+	"math/bits"
+	"testing"
+)
+
+func TestInt63s(t *testing.T) {
+	for _, n := range []int{0, 1, 7, maxBulkRead / 8, maxBulkRead/8 + 1, 100000} {
+		dst := make([]int64, n)
+		Int63s(dst)
+		var hi, lo [16]int
+		for i, x := range dst {
+			if x < 0 {
+				t.Fatalf("%d: #%d is negative: %d", n, i, x)
+			}
+			hi[x>>59]++
+			lo[x&15]++
+		}
+		if n < 10000 {
+			continue
+		}
+		checkUniform(t, hi[:])
+		checkUniform(t, lo[:])
+	}
+}
+
+func TestInt63sFillsAll(t *testing.T) {
+	// The probability of any of these being zero is
+	// negligible.
+	dst := make([]int64, 3*maxBulkRead/8+3)
+	Int63s(dst)
+	for i, x := range dst {
+		if x == 0 {
+			t.Fatalf("#%d was not filled", i)
+		}
+	}
+}
+
+func BenchmarkInt63s(b *testing.B) {
+	dst := make([]int64, 1024)
+	b.SetBytes(int64(len(dst) * 8))
+	for n := b.N; n > 0; n-- {
+		Int63s(dst)
+	}
+}
+
+func BenchmarkInt63sNaive(b *testing.B) {
+	dst := make([]int64, 1024)
+	b.SetBytes(int64(len(dst) * 8))
+	for n := b.N; n > 0; n-- {
+		for i := range dst {
+			dst[i] = Int63()
+		}
+	}
+}
+
+func TestUint64s(t *testing.T) {
+	for _, n := range []int{0, 1, 7, maxBulkRead / 8, maxBulkRead/8 + 1, 100000} {
+		dst := make([]uint64, n)
+		Uint64s(dst)
+		var hi, lo [16]int
+		var or uint64
+		and := ^uint64(0)
+		for _, x := range dst {
+			hi[x>>60]++
+			lo[x&15]++
+			or |= x
+			and &= x
+		}
+		if n < 10000 {
+			continue
+		}
+		if or != ^uint64(0) || and != 0 {
+			t.Fatalf("%d: some bits never change: or=%#x and=%#x", n, or, and)
+		}
+		checkUniform(t, hi[:])
+		checkUniform(t, lo[:])
+	}
+
+	// Every element should be filled, including those after
+	// the first block.
+	dst := make([]uint64, 3*maxBulkRead/8+3)
+	Uint64s(dst)
+	for i, x := range dst {
+		if x == 0 {
+			t.Fatalf("#%d was not filled", i)
+		}
+	}
+}
+
+func BenchmarkUint64s(b *testing.B) {
+	dst := make([]uint64, 1024)
+	b.SetBytes(int64(len(dst) * 8))
+	for n := b.N; n > 0; n-- {
+		Uint64s(dst)
+	}
+}
+
+func BenchmarkUint64sNaive(b *testing.B) {
+	dst := make([]uint64, 1024)
+	b.SetBytes(int64(len(dst) * 8))
+	for n := b.N; n > 0; n-- {
+		for i := range dst {
+			dst[i] = Uint64()
+		}
+	}
+}
+
+func TestFloat64s(t *testing.T) {
+	for _, n := range []int{0, 1, 7, maxBulkRead / 8, maxBulkRead/8 + 1, 100000} {
+		dst := make([]float64, n)
+		for i := range dst {
+			dst[i] = -1
+		}
+		Float64s(dst)
+		counts := make([]int, 20)
+		for i, x := range dst {
+			if !(x >= 0 && x < 1) {
+				t.Fatalf("%d: #%d out of range: %g", n, i, x)
+			}
+			counts[int(x*20)]++
+		}
+		if n < 10000 {
+			continue
+		}
+		checkUniform(t, counts)
+	}
+
+	// The largest word maps to the largest float64 below 1.
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(bytes.Repeat([]byte{0xff}, 8))))
+	var dst [1]float64
+	r.Float64s(dst[:])
+	if want := math.Nextafter(1, 0); dst[0] != want {
+		t.Fatalf("expected %g, got %g", want, dst[0])
+	}
+}
+
+func BenchmarkFloat64s(b *testing.B) {
+	dst := make([]float64, 1024)
+	b.SetBytes(int64(len(dst) * 8))
+	for n := b.N; n > 0; n-- {
+		Float64s(dst)
+	}
+}
+
+func BenchmarkFloat64sNaive(b *testing.B) {
+	dst := make([]float64, 1024)
+	b.SetBytes(int64(len(dst) * 8))
+	for n := b.N; n > 0; n-- {
+		for i := range dst {
+			dst[i] = Float64()
+		}
+	}
+}
+
+func TestBits(t *testing.T) {
+	for _, n := range []int{0, 1, 63, 64, 65, 1000} {
+		// OR many draws so that every reachable bit is set.
+		var or []uint64
+		total := 0
+		const draws = 200
+		for i := 0; i < draws; i++ {
+			b := Bits(n)
+			if len(b) != (n+63)/64 {
+				t.Fatalf("Bits(%d): got %d words", n, len(b))
+			}
+			if or == nil {
+				or = make([]uint64, len(b))
+			}
+			for j, x := range b {
+				or[j] |= x
+				total += bits.OnesCount64(x)
+			}
+		}
+		set := 0
+		for _, x := range or {
+			set += bits.OnesCount64(x)
+		}
+		if set != n {
+			t.Fatalf("Bits(%d): %d distinct bits set", n, set)
+		}
+		if k := n % 64; k != 0 && or[len(or)-1]>>k != 0 {
+			t.Fatalf("Bits(%d): excess bits set: %#x", n, or[len(or)-1])
+		}
+
+		// The population count has mean n/2 and standard
+		// deviation sqrt(n)/2 per draw.
+		if n < 64 {
+			continue
+		}
+		mean := float64(total) / draws
+		stddev := math.Sqrt(float64(n)) / 2 / math.Sqrt(draws)
+		if math.Abs(mean-float64(n)/2) > 5*stddev {
+			t.Fatalf("Bits(%d): mean population count %g, expected %g", n, mean, float64(n)/2)
+		}
+	}
+
+	checkPanics(t, "Bits(-1)", func() { Bits(-1) })
+}
+
+func TestRecords(t *testing.T) {
+	for _, tc := range [][2]int{{0, 0}, {0, 16}, {4, 0}, {1, 1}, {100, 16}, {3, 5000}} {
+		count, width := tc[0], tc[1]
+		recs := Records(count, width)
+		if len(recs) != count {
+			t.Fatalf("Records(%d, %d): got %d records", count, width, len(recs))
+		}
+		seen := make(map[string]bool)
+		for i, rec := range recs {
+			if len(rec) != width || cap(rec) != width {
+				t.Fatalf("Records(%d, %d): #%d has len %d, cap %d",
+					count, width, i, len(rec), cap(rec))
+			}
+			if width >= 8 {
+				if seen[string(rec)] {
+					t.Fatalf("Records(%d, %d): #%d repeats an earlier record", count, width, i)
+				}
+				seen[string(rec)] = true
+			}
+		}
+	}
+
+	// The records are read in order from the Source.
+	want := make([]byte, 12)
+	for i := range want {
+		want[i] = byte(i)
+	}
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(want)))
+	recs := r.Records(3, 4)
+	for i, rec := range recs {
+		if !bytes.Equal(rec, want[i*4:(i+1)*4]) {
+			t.Fatalf("#%d: expected %x, got %x", i, want[i*4:(i+1)*4], rec)
+		}
+	}
+	// Appending does not overwrite the next record.
+	_ = append(recs[0], 0xff)
+	if recs[1][0] != 4 {
+		t.Fatal("append overwrote the next record")
+	}
+
+	checkPanics(t, "Records(-1, 1)", func() { Records(-1, 1) })
+	checkPanics(t, "Records(1, -1)", func() { Records(1, -1) })
+	checkPanics(t, "Records(overflow)", func() { Records(math.MaxInt/2+1, 2) })
+}
+
+func BenchmarkRecords(b *testing.B) {
+	const count, width = 1000, 32
+	b.SetBytes(count * width)
+	for n := b.N; n > 0; n-- {
+		Records(count, width)
+	}
+}
+
+func BenchmarkRecordsNaive(b *testing.B) {
+	const count, width = 1000, 32
+	b.SetBytes(count * width)
+	for n := b.N; n > 0; n-- {
+		recs := make([][]byte, count)
+		for i := range recs {
+			recs[i] = Bytes(width)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+const (
+	// defaultReseedInterval is the number of bytes a
+	// ChaChaSource generates before reseeding.
+	defaultReseedInterval = 1 << 20
+	// maxReseedInterval is the largest reseed interval. It is
+	// well under the 256 GiB it takes to exhaust the
+	// ChaCha20 block counter.
+	maxReseedInterval = 1 << 36
+)
+
+// ChaChaSource is a Source that generates random values from
+// a ChaCha20 keystream keyed by crypto/rand.
+//
+// Unlike the Source returned by NewSource, it only reads from
+// crypto/rand when it reseeds. It reseeds periodically, when
+// the process forks, and after ForceReseed.
+//
+// A ChaChaSource is safe for concurrent use by multiple
+// goroutines.
+type ChaChaSource struct {
+	mu sync.Mutex
+	c  *chacha20.Cipher
+	// buf[off:] is unused keystream. buf[:off] has been
+	// handed out and zeroed.
+	buf [512]byte
+	off int
+	// n is the number of bytes generated since the last
+	// reseed.
+	n        int64
+	interval int64
+	// pid is the process ID at the last reseed.
+	pid int
+}
+
+var _ Source = (*ChaChaSource)(nil)
+
+// NewChaChaSource returns a ChaChaSource that reseeds itself
+// from crypto/rand after every 1 MiB of output.
+//
+// It panics if crypto/rand fails.
+func NewChaChaSource() *ChaChaSource {
+	return NewChaChaSourceInterval(0)
+}
+
+// NewChaChaSourceInterval returns a ChaChaSource that reseeds
+// itself from crypto/rand after every n bytes of output.
+//
+// If n <= 0, a default of 1 MiB is used. Intervals larger than
+// 64 GiB are reduced to 64 GiB.
+//
+// It panics if crypto/rand fails.
+func NewChaChaSourceInterval(n int64) *ChaChaSource {
+	if n <= 0 {
+		n = defaultReseedInterval
+	}
+	if n > maxReseedInterval {
+		n = maxReseedInterval
+	}
+	s := &ChaChaSource{interval: n}
+	if err := s.reseed(); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Reseed immediately rekeys the keystream from crypto/rand.
+//
+// Output generated after Reseed returns is independent of
+// output generated before.
+func (s *ChaChaSource) Reseed() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reseed()
+}
+
+// ForceReseed causes the next call to rekey the keystream from
+// crypto/rand.
+//
+// Unlike Reseed, it does not read from crypto/rand itself, so
+// it cannot fail.
+func (s *ChaChaSource) ForceReseed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.n = s.interval
+}
+
+// reseed rekeys the keystream from crypto/rand.
+//
+// s.mu must be held.
+func (s *ChaChaSource) reseed() error {
+	var seed [chacha20.KeySize + chacha20.NonceSize]byte
+	if _, err := rand.Read(seed[:]); err != nil {
+		return err
+	}
+	c, err := chacha20.NewUnauthenticatedCipher(
+		seed[:chacha20.KeySize], seed[chacha20.KeySize:])
+	wipe(seed[:])
+	if err != nil {
+		return err
+	}
+	s.c = c
+	s.n = 0
+	s.pid = getpid()
+	// Discard any keystream from the old key.
+	wipe(s.buf[s.off:])
+	s.off = len(s.buf)
+	return nil
+}
+
+func (*ChaChaSource) Seed(_ uint64) {}
+
+func (s *ChaChaSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *ChaChaSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.n >= s.interval || s.pid != getpid() {
+		if err := s.reseed(); err != nil {
+			panic(err)
+		}
+	}
+	if s.off == len(s.buf) {
+		// buf is all zeros, so this writes the raw keystream.
+		s.c.XORKeyStream(s.buf[:], s.buf[:])
+		s.off = 0
+	}
+	b := s.buf[s.off : s.off+8]
+	x := binary.LittleEndian.Uint64(b)
+	wipe(b)
+	s.off += 8
+	s.n += 8
+	return x
+}
@@ -0,0 +1,103 @@
+package saferand
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestChaChaSourceUniform(t *testing.T) {
+	src := NewChaChaSource()
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x := src.Uint64()
+		hi[x>>60]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+}
+
+func TestChaChaSourceReseedInterval(t *testing.T) {
+	const interval = 64
+	src := NewChaChaSourceInterval(interval)
+	for i := 0; i < 10; i++ {
+		c := src.c
+		for src.n < interval {
+			src.Uint64()
+			if src.c != c {
+				t.Fatalf("#%d: reseeded after %d bytes", i, src.n)
+			}
+		}
+		src.Uint64()
+		if src.c == c {
+			t.Fatalf("#%d: did not reseed after %d bytes", i, interval)
+		}
+		if src.n != 8 {
+			t.Fatalf("#%d: expected 8 bytes after reseed, got %d", i, src.n)
+		}
+	}
+
+	var hi [16]int
+	for i := 0; i < 100000; i++ {
+		hi[src.Uint64()>>60]++
+	}
+	checkUniform(t, hi[:])
+}
+
+func TestChaChaSourceReseed(t *testing.T) {
+	src := NewChaChaSource()
+	src.Uint64()
+	c := src.c
+	if err := src.Reseed(); err != nil {
+		t.Fatal(err)
+	}
+	if src.c == c {
+		t.Fatal("Reseed did not rekey")
+	}
+	if src.off != len(src.buf) {
+		t.Fatalf("Reseed kept %d bytes of old keystream", len(src.buf)-src.off)
+	}
+	for i, c := range src.buf {
+		if c != 0 {
+			t.Fatalf("byte %d not zeroed: %#x", i, c)
+		}
+	}
+}
+
+func TestChaChaSourceDefaults(t *testing.T) {
+	for _, tc := range []struct {
+		n, want int64
+	}{
+		{-1, defaultReseedInterval},
+		{0, defaultReseedInterval},
+		{1, 1},
+		{maxReseedInterval + 1, maxReseedInterval},
+	} {
+		if got := NewChaChaSourceInterval(tc.n).interval; got != tc.want {
+			t.Fatalf("%d: expected %d, got %d", tc.n, tc.want, got)
+		}
+	}
+}
+
+func TestChaChaSourceConcurrent(t *testing.T) {
+	src := NewChaChaSourceInterval(128)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				src.Uint64()
+			}
+			src.Reseed()
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkChaChaSource(b *testing.B) {
+	src := NewChaChaSource()
+	for n := b.N; n > 0; n-- {
+		src.Uint64()
+	}
+}
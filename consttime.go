@@ -0,0 +1,62 @@
+package saferand
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// constTimeBatch is the number of candidates Int63nConstTime
+// draws up front.
+const constTimeBatch = 16
+
+// Int63nConstTime returns a uniform random number in [0, n).
+//
+// It panics if n <= 0.
+func Int63nConstTime(n int64) int64 { return defaultRand.Int63nConstTime(n) }
+
+// Int63nConstTime returns a uniform random number in [0, n).
+//
+// Int63n and Int63nFast reject and redraw biased values, so the
+// time they take depends on the values drawn. Int63nConstTime
+// instead reads 16 candidates with a single read. It reduces
+// each one with Lemire's method and selects the first
+// acceptable one without branching on secret data. The number
+// of reads and the operations performed depend only on n.
+//
+// Each candidate is rejected with probability (2^64 mod n)/2^64,
+// which is at most about 1/3. For most n, it is far lower. If
+// all 16 candidates are rejected, which happens with
+// probability at most about 3^-16 (≈2^-25), worst for n just
+// above 2^64/3, it falls back to Int63nFast. That fallback
+// path is not constant time.
+//
+// The guarantee only covers this function. The Rand's Source,
+// for example crypto/rand, has its own timing behavior.
+//
+// It panics if n <= 0.
+func (r *Rand) Int63nConstTime(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63nConstTime")
+	}
+	un := uint64(n)
+	t := -un % un // 2^64 mod n, which depends only on n
+
+	var buf [constTimeBatch * 8]byte
+	r.fill(buf[:])
+	var res, found uint64
+	for i := 0; i < constTimeBatch; i++ {
+		hi, lo := bits.Mul64(binary.LittleEndian.Uint64(buf[i*8:]), un)
+		// ok is 1 if lo >= t.
+		_, borrow := bits.Sub64(lo, t, 0)
+		ok := borrow ^ 1
+		// Take hi if it is the first acceptable candidate.
+		take := ok &^ found
+		res |= hi & -take
+		found |= ok
+	}
+	wipe(buf[:])
+	if found == 0 {
+		return int64(r.uint64nFast(un))
+	}
+	return int64(res)
+}
@@ -0,0 +1,71 @@
+package saferand
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestInt63nConstTime(t *testing.T) {
+	for _, n := range []int64{1, 2, 3, 7, 10, 255} {
+		counts := make([]int, n)
+		for i := 0; i < 50000; i++ {
+			x := Int63nConstTime(n)
+			if x < 0 || x >= n {
+				t.Fatalf("Int63nConstTime(%d): out of range: %d", n, x)
+			}
+			counts[x]++
+		}
+		checkUniform(t, counts)
+	}
+
+	// The top half of a large range.
+	const big = 1<<62 + 1
+	var halves [2]int
+	for i := 0; i < 50000; i++ {
+		halves[Int63nConstTime(big)/(big/2+1)]++
+	}
+	checkUniform(t, halves[:])
+
+	checkPanics(t, "Int63nConstTime(0)", func() { Int63nConstTime(0) })
+	checkPanics(t, "Int63nConstTime(-1)", func() { Int63nConstTime(-1) })
+}
+
+func TestInt63nConstTimeSelect(t *testing.T) {
+	// With n = 3, 2^64 mod 3 = 1, so only x*3 mod 2^64 == 0,
+	// that is x == 0, is rejected.
+	candidates := func(xs ...uint64) *Rand {
+		buf := make([]byte, constTimeBatch*8)
+		for i := 0; i < constTimeBatch; i++ {
+			x := xs[len(xs)-1]
+			if i < len(xs) {
+				x = xs[i]
+			}
+			binary.LittleEndian.PutUint64(buf[i*8:], x)
+		}
+		return NewWithSource(NewSourceFromReader(bytes.NewReader(buf)))
+	}
+
+	// The first acceptable candidate wins.
+	if got := candidates(0, 0, 1<<63, 1<<62).Int63nConstTime(3); got != 1 {
+		t.Fatalf("expected 1, got %d", got)
+	}
+	if got := candidates(1<<62+1<<61, 0).Int63nConstTime(3); got != 1 {
+		t.Fatalf("expected 1, got %d", got)
+	}
+
+	// Every candidate rejected: fall back to Int63nFast, which
+	// reads more entropy.
+	buf := make([]byte, constTimeBatch*8+8)
+	binary.LittleEndian.PutUint64(buf[constTimeBatch*8:], 1<<63)
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(buf)))
+	if got := r.Int63nConstTime(3); got != 1 {
+		t.Fatalf("fallback: expected 1, got %d", got)
+	}
+}
+
+func BenchmarkInt63nConstTime(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Int63nConstTime(1e9 + 7)
+	}
+}
@@ -0,0 +1,97 @@
+package saferand
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// SourceStats records how much a Source returned by
+// NewCountingSource has been used.
+//
+// Its methods are safe for concurrent use by multiple
+// goroutines.
+type SourceStats struct {
+	// Accessed atomically. Keep first so they are 64-bit
+	// aligned on 32-bit platforms.
+	int63  uint64
+	uint64 uint64
+	bytes  uint64
+}
+
+// Int63Calls returns the number of calls to Int63.
+func (s *SourceStats) Int63Calls() uint64 {
+	return atomic.LoadUint64(&s.int63)
+}
+
+// Uint64Calls returns the number of calls to Uint64.
+func (s *SourceStats) Uint64Calls() uint64 {
+	return atomic.LoadUint64(&s.uint64)
+}
+
+// BytesRead returns the number of bytes drawn from the Source.
+//
+// Each call to Int63 or Uint64 counts as eight bytes. Calls to
+// Read count the number of bytes returned.
+func (s *SourceStats) BytesRead() uint64 {
+	return atomic.LoadUint64(&s.bytes)
+}
+
+// countingSource is a Source that records its usage.
+type countingSource struct {
+	inner Source
+	stats *SourceStats
+}
+
+var (
+	_ Source    = countingSource{}
+	_ io.Reader = countingSource{}
+)
+
+// NewCountingSource returns a Source that wraps inner and
+// records its usage in the returned SourceStats.
+//
+// The returned Source is safe for concurrent use if inner is.
+func NewCountingSource(inner Source) (Source, *SourceStats) {
+	stats := new(SourceStats)
+	return countingSource{inner: inner, stats: stats}, stats
+}
+
+func (s countingSource) Seed(seed uint64) {
+	s.inner.Seed(seed)
+}
+
+func (s countingSource) Int63() int64 {
+	atomic.AddUint64(&s.stats.int63, 1)
+	atomic.AddUint64(&s.stats.bytes, 8)
+	if src, ok := s.inner.(interface{ Int63() int64 }); ok {
+		return src.Int63()
+	}
+	return int64(s.inner.Uint64() >> 1)
+}
+
+func (s countingSource) Uint64() uint64 {
+	atomic.AddUint64(&s.stats.uint64, 1)
+	atomic.AddUint64(&s.stats.bytes, 8)
+	return s.inner.Uint64()
+}
+
+// Read fills p with random bytes.
+//
+// If inner implements io.Reader, Read reads directly from it.
+// Otherwise, it fills p using Uint64, and each of those calls
+// is counted like any other call to Uint64.
+func (s countingSource) Read(p []byte) (int, error) {
+	if r, ok := s.inner.(io.Reader); ok {
+		n, err := r.Read(p)
+		atomic.AddUint64(&s.stats.bytes, uint64(n))
+		return n, err
+	}
+	for i := 0; i < len(p); i += 8 {
+		x := s.Uint64()
+		for j := i; j < i+8 && j < len(p); j++ {
+			p[j] = byte(x)
+			x >>= 8
+		}
+	}
+	return len(p), nil
+}
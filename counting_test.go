@@ -0,0 +1,73 @@
+package saferand
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCountingSource(t *testing.T) {
+	src, stats := NewCountingSource(NewSource())
+	for i := 0; i < 3; i++ {
+		src.(interface{ Int63() int64 }).Int63()
+	}
+	for i := 0; i < 5; i++ {
+		src.Uint64()
+	}
+	r := NewWithSource(src)
+	if _, err := r.Read(make([]byte, 13)); err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.Int63Calls(); got != 3 {
+		t.Errorf("Int63Calls: got %d, expected 3", got)
+	}
+	if got := stats.Uint64Calls(); got != 5 {
+		t.Errorf("Uint64Calls: got %d, expected 5", got)
+	}
+	if got, want := stats.BytesRead(), uint64(3*8+5*8+13); got != want {
+		t.Errorf("BytesRead: got %d, expected %d", got, want)
+	}
+}
+
+// TestCountingSourceNotReader tests wrapping a Source that does
+// not implement io.Reader or Int63.
+func TestCountingSourceNotReader(t *testing.T) {
+	src, stats := NewCountingSource(fixedSource(1<<63 | 42))
+	if got := src.(interface{ Int63() int64 }).Int63(); got != 42 {
+		t.Errorf("Int63: got %d, expected 42", got)
+	}
+	r := NewWithSource(src)
+	if _, err := r.Read(make([]byte, 13)); err != nil {
+		t.Fatal(err)
+	}
+	if got := stats.Uint64Calls(); got != 2 {
+		t.Errorf("Uint64Calls: got %d, expected 2", got)
+	}
+	if got, want := stats.BytesRead(), uint64(8+2*8); got != want {
+		t.Errorf("BytesRead: got %d, expected %d", got, want)
+	}
+}
+
+func TestCountingSourceConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		calls      = 1000
+	)
+	src, stats := NewCountingSource(NewSource())
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < calls; j++ {
+				src.Uint64()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := stats.Uint64Calls(); got != goroutines*calls {
+		t.Errorf("Uint64Calls: got %d, expected %d", got, goroutines*calls)
+	}
+	if got := stats.BytesRead(); got != 8*goroutines*calls {
+		t.Errorf("BytesRead: got %d, expected %d", got, 8*goroutines*calls)
+	}
+}
@@ -0,0 +1,312 @@
+package saferand
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// This file implements CTR_DRBG from NIST SP 800-90A Rev. 1,
+// section 10.2, using AES-256 without a derivation function.
+
+const (
+	ctrKeySize  = 32
+	ctrSeedSize = ctrKeySize + aes.BlockSize
+	// ctrMaxRequest is the maximum number of bytes per
+	// generate request: 2^19 bits for AES.
+	ctrMaxRequest = 1 << 16
+	// ctrReseedInterval is the maximum number of generate
+	// requests between reseeds: 2^48 for AES.
+	ctrReseedInterval = 1 << 48
+)
+
+var errInputTooLong = errors.New("saferand: CTR_DRBG input exceeds 48 bytes")
+
+// ctrDRBG is the CTR_DRBG internal state.
+type ctrDRBG struct {
+	c cipher.Block
+	v [aes.BlockSize]byte
+	// counter is the reseed counter: the number of generate
+	// requests since the last (re)seed, plus one.
+	counter uint64
+}
+
+// ctrPad zero-pads b to the seed length.
+//
+// It returns nil if b is empty.
+func ctrPad(b []byte) (*[ctrSeedSize]byte, error) {
+	if len(b) > ctrSeedSize {
+		return nil, errInputTooLong
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	var p [ctrSeedSize]byte
+	copy(p[:], b)
+	return &p, nil
+}
+
+// instantiate implements CTR_DRBG_Instantiate_algorithm.
+//
+// perso may be nil.
+func (d *ctrDRBG) instantiate(entropy, perso *[ctrSeedSize]byte) {
+	var key [ctrKeySize]byte
+	d.c, _ = aes.NewCipher(key[:])
+	d.v = [aes.BlockSize]byte{}
+	d.reseed(entropy, perso)
+}
+
+// reseed implements CTR_DRBG_Reseed_algorithm.
+//
+// additional may be nil.
+func (d *ctrDRBG) reseed(entropy, additional *[ctrSeedSize]byte) {
+	seed := *entropy
+	if additional != nil {
+		for i := range seed {
+			seed[i] ^= additional[i]
+		}
+	}
+	d.update(&seed)
+	wipe(seed[:])
+	d.counter = 1
+}
+
+// generate implements CTR_DRBG_Generate_algorithm.
+//
+// additional may be nil. len(out) must be at most
+// ctrMaxRequest and d.counter must be at most
+// ctrReseedInterval.
+func (d *ctrDRBG) generate(out []byte, additional *[ctrSeedSize]byte) {
+	if additional != nil {
+		d.update(additional)
+	}
+	var block [aes.BlockSize]byte
+	for len(out) > 0 {
+		d.incr()
+		d.c.Encrypt(block[:], d.v[:])
+		n := copy(out, block[:])
+		out = out[n:]
+	}
+	wipe(block[:])
+	d.update(additional)
+	d.counter++
+}
+
+// update implements CTR_DRBG_Update.
+//
+// data may be nil, which is equivalent to all zeros.
+func (d *ctrDRBG) update(data *[ctrSeedSize]byte) {
+	var temp [ctrSeedSize]byte
+	for i := 0; i < len(temp); i += aes.BlockSize {
+		d.incr()
+		d.c.Encrypt(temp[i:], d.v[:])
+	}
+	if data != nil {
+		for i := range temp {
+			temp[i] ^= data[i]
+		}
+	}
+	d.c, _ = aes.NewCipher(temp[:ctrKeySize])
+	copy(d.v[:], temp[ctrKeySize:])
+	wipe(temp[:])
+}
+
+// incr increments V, a 128-bit big-endian counter.
+func (d *ctrDRBG) incr() {
+	hi := binary.BigEndian.Uint64(d.v[:8])
+	lo := binary.BigEndian.Uint64(d.v[8:])
+	lo++
+	if lo == 0 {
+		hi++
+	}
+	binary.BigEndian.PutUint64(d.v[:8], hi)
+	binary.BigEndian.PutUint64(d.v[8:], lo)
+}
+
+// CTRDRBGSource is a Source backed by an AES-256 CTR_DRBG as
+// specified in NIST SP 800-90A Rev. 1, without a derivation
+// function.
+//
+// It is instantiated and reseeded from crypto/rand. It
+// automatically reseeds after 2^48 generate requests, when the
+// process forks, and after ForceReseed.
+//
+// A CTRDRBGSource is safe for concurrent use by multiple
+// goroutines.
+type CTRDRBGSource struct {
+	mu sync.Mutex
+	d  ctrDRBG
+	// buf[off:] is unused output. buf[:off] has been handed
+	// out and zeroed.
+	buf [512]byte
+	off int
+	// pid is the process ID at the last reseed.
+	pid int
+	// force is set by ForceReseed.
+	force bool
+}
+
+var _ Source = (*CTRDRBGSource)(nil)
+
+// NewCTRDRBGSource returns a CTRDRBGSource instantiated from
+// crypto/rand and the optional personalization string.
+//
+// It returns an error if personalization is longer than 48
+// bytes or if crypto/rand fails.
+func NewCTRDRBGSource(personalization []byte) (*CTRDRBGSource, error) {
+	perso, err := ctrPad(personalization)
+	if err != nil {
+		return nil, err
+	}
+	var entropy [ctrSeedSize]byte
+	if _, err := rand.Read(entropy[:]); err != nil {
+		return nil, err
+	}
+	s := &CTRDRBGSource{}
+	s.d.instantiate(&entropy, perso)
+	wipe(entropy[:])
+	s.off = len(s.buf)
+	s.pid = getpid()
+	return s, nil
+}
+
+// Reseed reseeds the DRBG from crypto/rand and the optional
+// additional input.
+//
+// It returns an error if additionalInput is longer than 48
+// bytes or if crypto/rand fails.
+func (s *CTRDRBGSource) Reseed(additionalInput []byte) error {
+	add, err := ctrPad(additionalInput)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reseed(add)
+}
+
+// ForceReseed discards any buffered output and causes the next
+// call to reseed the DRBG from crypto/rand.
+//
+// Unlike Reseed, it does not read from crypto/rand itself, so
+// it cannot fail.
+func (s *CTRDRBGSource) ForceReseed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wipe(s.buf[s.off:])
+	s.off = len(s.buf)
+	s.force = true
+}
+
+// reseed reseeds the DRBG from crypto/rand and discards any
+// buffered output.
+//
+// s.mu must be held.
+func (s *CTRDRBGSource) reseed(add *[ctrSeedSize]byte) error {
+	var entropy [ctrSeedSize]byte
+	if _, err := rand.Read(entropy[:]); err != nil {
+		return err
+	}
+	s.d.reseed(&entropy, add)
+	wipe(entropy[:])
+	wipe(s.buf[s.off:])
+	s.off = len(s.buf)
+	s.pid = getpid()
+	s.force = false
+	return nil
+}
+
+// generate fills p with output from the DRBG, reseeding as
+// needed.
+//
+// add is the additional input for each generate request and
+// may be nil. As required by SP 800-90A, if a request needs to
+// reseed first, add is used for the reseed instead.
+//
+// s.mu must be held.
+func (s *CTRDRBGSource) generate(p []byte, add *[ctrSeedSize]byte) error {
+	for len(p) > 0 {
+		in := add
+		if s.d.counter > ctrReseedInterval || s.force || s.pid != getpid() {
+			if err := s.reseed(add); err != nil {
+				return err
+			}
+			in = nil
+		}
+		n := len(p)
+		if n > ctrMaxRequest {
+			n = ctrMaxRequest
+		}
+		s.d.generate(p[:n], in)
+		p = p[n:]
+	}
+	return nil
+}
+
+func (*CTRDRBGSource) Seed(_ uint64) {}
+
+func (s *CTRDRBGSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *CTRDRBGSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.off == len(s.buf) || s.pid != getpid() {
+		if err := s.generate(s.buf[:], nil); err != nil {
+			panic(err)
+		}
+		s.off = 0
+	}
+	b := s.buf[s.off : s.off+8]
+	x := binary.LittleEndian.Uint64(b)
+	wipe(b)
+	s.off += 8
+	return x
+}
+
+// Read fills p with output from the DRBG.
+//
+// It returns an error only if an automatic reseed fails.
+func (s *CTRDRBGSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.generate(p, nil); err != nil {
+		wipe(p)
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// GenerateWithInput fills dst with output from the DRBG,
+// mixing additionalInput into the state as specified by SP
+// 800-90A.
+//
+// The additional input binds the output to a context, such as
+// a request ID, for domain separation. It does not need to be
+// secret. An empty additionalInput is equivalent to Read.
+// Requests longer than 2^16 bytes are split into multiple
+// generate requests, and each one uses additionalInput.
+//
+// It returns an error if additionalInput is longer than 48
+// bytes or if an automatic reseed fails.
+func (s *CTRDRBGSource) GenerateWithInput(dst, additionalInput []byte) error {
+	add, err := ctrPad(additionalInput)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.generate(dst, add); err != nil {
+		wipe(dst)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package saferand
+
+import (
+	"bytes"
+	"encoding/hex"
+	"sync"
+	"testing"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func seedPtr(t *testing.T, s string) *[ctrSeedSize]byte {
+	t.Helper()
+	var p [ctrSeedSize]byte
+	b := unhex(t, s)
+	if len(b) != len(p) {
+		t.Fatalf("expected %d bytes, got %d", len(p), len(b))
+	}
+	copy(p[:], b)
+	return &p
+}
+
+// TestCTRDRBGVector tests an AES-256 CTR_DRBG (no derivation
+// function) vector from NIST's ACVP server.
+//
+// https://github.com/usnistgov/ACVP-Server/blob/fb44dce/gen-val/json-files/ctrDRBG-1.0/prompt.json#L4447-L4482
+func TestCTRDRBGVector(t *testing.T) {
+	var (
+		entropy          = seedPtr(t, "9FCBB4CCC0135C484BDED061DA9FD70748682FE84166B97FF53F9AA1909B2E95D3D529C0F453B3AC575D12AA441CC5CD")
+		perso            = seedPtr(t, "2C9FED0B39556CDBE699EBCA2A0EC7EECB287E8744475050C572FA8AE9ED0A4A7D6F1CABF1C4278532FB20AF7D64BD32")
+		reseedEntropy    = seedPtr(t, "913C0DA19B010EDDD55A7A4F3F713EEF5B1534D34360A7EC376AE71A6B340043CC7726F762CB853453F399B3A645062A")
+		reseedAdditional = seedPtr(t, "2D9D4EC141A22E6CD2F6EE4F6719CF6BDF95CFE50B8D5EA6C87D38B4B872706FFF80B0380BB90E9C42D11D6526E56C29")
+		additional1      = seedPtr(t, "A642F06D327828F3E84564A3E37D60C157073B95864CA07981B0189668A0D978CD5DC68F06801CEFF0DC839A312B028E")
+		additional2      = seedPtr(t, "9DB14BABFA9107C88BA92073C0B4A65E89147EA06D74B894142979482F452915B35B5636F9B8A951759735ADE7C8D5D1")
+		want             = unhex(t, "F10C645683FF0131254052ED4C698122B46B563654C29D728AC191CA4AAEFE649EEFE4C6FC33B25BB739294DD5CF578099F856C98D98000CBF971F1E6EA900822FF8C110118F6520471744D3F8A3F5C7D568494240E57F5488AF9C9F9F4E7322F56CCD843C0DBFCE9170C02E205389420527F23EDB3369D9FCC5E34901B5BA4EB71B973FC7982FFE0899FF7FE53EE0C4F51A3EF93EF9C6D4D279DD7536F8776BE94AAA05E89EF6E6AEE8832B4B42FFCA5FB91EC0273F9EF945865512889B0C5EE141D1B38DF827D2A694835561628C6F9B093A01A835F07ADBB9E03FEBF93389E8F3B86E1E0ABF1F9958FA286AD995289C2F606D1A9043A166C1AFE8D00769C712650819C9068A4BD22717C98338395A7BA6E95B5178BFBF4EFB0F05A91713BA8BF2127A6BA1EDFA6D1CAB05C03EE0D2AFE1DA4EB8F2C579EC872FF4B602027EF4BDCF2F4B01423F8E600A13D7CACB6AB83263BA58F907694AF614A6724FD0E4C627A0D91DDC6716C697FACE6F4808A4F37B731DE4E0CD4766CEADAAAF47992505299C72AC1A6E9A8335B8D7E501B3841188D0DA4DE5267674444DC2B0CF9F010756FA865A25CA3F1B24C34E845B2259926B6A867A7684DE68A6137C4FB0F47A2E54AE9E6455BEBA0B0A9629644FE9E378EE95386443BA977124FFD1192E9F460684C7B09FA99F5F93F04F56FD7955E042187887CE696F1934017E458B16B5C9")
+	)
+
+	var d ctrDRBG
+	d.instantiate(entropy, perso)
+	d.reseed(reseedEntropy, reseedAdditional)
+	got := make([]byte, len(want))
+	d.generate(got, additional1)
+	d.generate(got, additional2)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("expected\n%x\ngot\n%x", want, got)
+	}
+	if d.counter != 3 {
+		t.Fatalf("expected reseed counter 3, got %d", d.counter)
+	}
+}
+
+// TestCTRDRBGCAST tests the CTR_DRBG self-test vector used by
+// Go's FIPS 140-3 module (crypto/internal/fips140/drbg).
+func TestCTRDRBGCAST(t *testing.T) {
+	var entropy, reseedEntropy, additional [ctrSeedSize]byte
+	for i := range entropy {
+		entropy[i] = byte(0x01 + i)
+		reseedEntropy[i] = byte(0x31 + i)
+		additional[i] = byte(0x61 + i)
+	}
+	want := unhex(t, "6e6e479d24f86a3b7787a8f8186d985a53bebeeddeab9228f0f4ac6e10bf0193")
+
+	var d ctrDRBG
+	d.instantiate(&entropy, nil)
+	d.reseed(&reseedEntropy, &additional)
+	got := make([]byte, len(want))
+	d.generate(got, &additional)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("expected %x, got %x", want, got)
+	}
+}
+
+func TestCTRDRBGSource(t *testing.T) {
+	src, err := NewCTRDRBGSource([]byte("saferand test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x := src.Uint64()
+		hi[x>>60]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+
+	// Larger than a single generate request.
+	p := make([]byte, 3*ctrMaxRequest+5)
+	if n, err := src.Read(p); n != len(p) || err != nil {
+		t.Fatalf("Read: got (%d, %v)", n, err)
+	}
+	if bytes.Equal(p[:ctrMaxRequest], p[ctrMaxRequest:2*ctrMaxRequest]) {
+		t.Fatal("Read repeated output")
+	}
+
+	if err := src.Reseed([]byte("additional input")); err != nil {
+		t.Fatal(err)
+	}
+	if src.d.counter != 1 {
+		t.Fatalf("expected reseed counter 1, got %d", src.d.counter)
+	}
+}
+
+func TestCTRDRBGSourceAutoReseed(t *testing.T) {
+	src, err := NewCTRDRBGSource(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.d.counter = ctrReseedInterval
+	src.Uint64()
+	if src.d.counter != ctrReseedInterval+1 {
+		t.Fatalf("unexpected reseed counter %d", src.d.counter)
+	}
+	// The next generate request exceeds the interval.
+	src.Read(make([]byte, 1))
+	if src.d.counter != 2 {
+		t.Fatalf("expected reseed counter 2, got %d", src.d.counter)
+	}
+}
+
+func TestCTRDRBGInputTooLong(t *testing.T) {
+	long := make([]byte, ctrSeedSize+1)
+	if _, err := NewCTRDRBGSource(long); err == nil {
+		t.Fatal("expected an error")
+	}
+	src, err := NewCTRDRBGSource(long[:ctrSeedSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := src.Reseed(long); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+// newTestCTRDRBGSource returns a CTRDRBGSource instantiated
+// from a fixed seed.
+func newTestCTRDRBGSource() *CTRDRBGSource {
+	var entropy [ctrSeedSize]byte
+	for i := range entropy {
+		entropy[i] = byte(i)
+	}
+	s := &CTRDRBGSource{}
+	s.d.instantiate(&entropy, nil)
+	s.off = len(s.buf)
+	s.pid = getpid()
+	return s
+}
+
+func TestCTRDRBGGenerateWithInput(t *testing.T) {
+	const n = 2*ctrMaxRequest + 7
+
+	// Empty additional input matches Read.
+	want := make([]byte, n)
+	newTestCTRDRBGSource().Read(want)
+	for _, in := range [][]byte{nil, {}} {
+		got := make([]byte, n)
+		if err := newTestCTRDRBGSource().GenerateWithInput(got, in); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("%#v: output differs from Read", in)
+		}
+	}
+
+	// Different inputs produce different streams, and the
+	// same input produces the same stream.
+	a := make([]byte, n)
+	b := make([]byte, n)
+	newTestCTRDRBGSource().GenerateWithInput(a, []byte("request 1"))
+	newTestCTRDRBGSource().GenerateWithInput(b, []byte("request 2"))
+	for i := 0; i < n; i += ctrMaxRequest {
+		j := i + 32
+		if j > n {
+			j = n
+		}
+		if bytes.Equal(a[i:j], b[i:j]) || bytes.Equal(a[i:j], want[i:j]) {
+			t.Fatalf("request at offset %d did not use the additional input", i)
+		}
+	}
+	newTestCTRDRBGSource().GenerateWithInput(b, []byte("request 1"))
+	if !bytes.Equal(a, b) {
+		t.Fatal("same additional input produced different output")
+	}
+
+	// The additional input also changes later output.
+	s1, s2 := newTestCTRDRBGSource(), newTestCTRDRBGSource()
+	s1.GenerateWithInput(a[:16], []byte("x"))
+	s2.GenerateWithInput(b[:16], []byte("y"))
+	if s1.Uint64() == s2.Uint64() {
+		t.Fatal("additional input did not update the state")
+	}
+
+	// A reseed takes the additional input instead of the
+	// generate request.
+	s := newTestCTRDRBGSource()
+	s.d.counter = ctrReseedInterval + 1
+	if err := s.GenerateWithInput(a[:16], []byte("reseed")); err != nil {
+		t.Fatal(err)
+	}
+	if s.d.counter != 2 {
+		t.Fatalf("expected reseed counter 2, got %d", s.d.counter)
+	}
+
+	if err := newTestCTRDRBGSource().GenerateWithInput(a, make([]byte, ctrSeedSize+1)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestCTRDRBGSourceConcurrent(t *testing.T) {
+	src, err := NewCTRDRBGSource(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				src.Uint64()
+			}
+			src.Read(make([]byte, 100))
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkCTRDRBGSource(b *testing.B) {
+	src, err := NewCTRDRBGSource(nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for n := b.N; n > 0; n-- {
+		src.Uint64()
+	}
+}
+
+// TestCTRDRBGInt63 checks that Int63 drops the low bit of
+// Uint64, like ExpSource.
+func TestCTRDRBGInt63(t *testing.T) {
+	a, b := newTestCTRDRBGSource(), newTestCTRDRBGSource()
+	for i := 0; i < 100; i++ {
+		if x, y := a.Int63(), b.Uint64(); x != int64(y>>1) {
+			t.Fatalf("#%d: Int63 = %#x, expected %#x", i, x, y>>1)
+		}
+	}
+}
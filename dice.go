@@ -0,0 +1,59 @@
+package saferand
+
+import (
+	"math"
+)
+
+// Dice returns n independent rolls of a die with the given
+// number of sides. Each roll is in [1, sides].
+//
+// It panics if n < 0 or sides < 1.
+func Dice(n, sides int) []int { return defaultRand.Dice(n, sides) }
+
+// DiceSum returns the sum of n independent rolls of a die with
+// the given number of sides.
+//
+// It panics if n < 0, sides < 1, or the sum could overflow an
+// int.
+func DiceSum(n, sides int) int { return defaultRand.DiceSum(n, sides) }
+
+// Dice returns n independent rolls of a die with the given
+// number of sides. Each roll is in [1, sides].
+//
+// It panics if n < 0 or sides < 1.
+func (r *Rand) Dice(n, sides int) []int {
+	if n < 0 || sides < 1 {
+		panic("invalid argument to Dice")
+	}
+	rolls := make([]int, n)
+	for i := range rolls {
+		rolls[i] = r.roll(sides)
+	}
+	return rolls
+}
+
+// DiceSum returns the sum of n independent rolls of a die with
+// the given number of sides.
+//
+// It is equivalent to summing the result of Dice, but does
+// not allocate.
+//
+// It panics if n < 0, sides < 1, or the sum could overflow an
+// int.
+func (r *Rand) DiceSum(n, sides int) int {
+	if n < 0 || sides < 1 || (n > 0 && sides > math.MaxInt/n) {
+		panic("invalid argument to DiceSum")
+	}
+	sum := 0
+	for i := 0; i < n; i++ {
+		sum += r.roll(sides)
+	}
+	return sum
+}
+
+// roll returns a uniform random number in [1, sides].
+//
+// sides must be positive.
+func (r *Rand) roll(sides int) int {
+	return 1 + int(r.Uint64n(uint64(sides)))
+}
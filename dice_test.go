@@ -0,0 +1,76 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDice(t *testing.T) {
+	for _, tc := range []struct {
+		n, sides int
+	}{
+		{0, 6},
+		{1, 1},
+		{3, 6},
+		{100, 20},
+		{10, math.MaxInt},
+	} {
+		rolls := Dice(tc.n, tc.sides)
+		if len(rolls) != tc.n {
+			t.Fatalf("Dice(%d, %d): got %d rolls", tc.n, tc.sides, len(rolls))
+		}
+		for _, x := range rolls {
+			if x < 1 || x > tc.sides {
+				t.Fatalf("Dice(%d, %d): out of range: %d", tc.n, tc.sides, x)
+			}
+		}
+	}
+
+	counts := make([]int, 6)
+	for i := 0; i < 10000; i++ {
+		for _, x := range Dice(10, 6) {
+			counts[x-1]++
+		}
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "Dice(-1, 6)", func() { Dice(-1, 6) })
+	checkPanics(t, "Dice(1, 0)", func() { Dice(1, 0) })
+}
+
+func TestDiceSum(t *testing.T) {
+	// With the same deterministic Source, DiceSum must equal
+	// the sum of the rolls returned by Dice.
+	for _, tc := range []struct {
+		n, sides int
+	}{
+		{0, 6},
+		{1, 1},
+		{3, 6},
+		{100, 20},
+	} {
+		a := NewWithSource(NewDeterministicSource(uint64(tc.n)))
+		b := NewWithSource(NewDeterministicSource(uint64(tc.n)))
+		want := 0
+		for _, x := range a.Dice(tc.n, tc.sides) {
+			want += x
+		}
+		if got := b.DiceSum(tc.n, tc.sides); got != want {
+			t.Fatalf("DiceSum(%d, %d): got %d, expected %d",
+				tc.n, tc.sides, got, want)
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		if x := DiceSum(3, 6); x < 3 || x > 18 {
+			t.Fatalf("DiceSum(3, 6): out of range: %d", x)
+		}
+	}
+	if x := DiceSum(5, 1); x != 5 {
+		t.Fatalf("DiceSum(5, 1): got %d", x)
+	}
+
+	checkPanics(t, "DiceSum(-1, 6)", func() { DiceSum(-1, 6) })
+	checkPanics(t, "DiceSum(1, 0)", func() { DiceSum(1, 0) })
+	checkPanics(t, "DiceSum(2, math.MaxInt)", func() { DiceSum(2, math.MaxInt) })
+}
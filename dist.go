@@ -0,0 +1,1083 @@
+package saferand
+
+import (
+	"math"
+)
+
+// Normal returns a normally distributed float64 with the
+// provided mean and standard deviation.
+//
+// It panics if stddev < 0.
+func Normal(mean, stddev float64) float64 { return defaultRand.Normal(mean, stddev) }
+
+// Normals fills dst with normally distributed float64s with the
+// provided mean and standard deviation.
+//
+// It panics if stddev < 0.
+func Normals(dst []float64, mean, stddev float64) { defaultRand.Normals(dst, mean, stddev) }
+
+// Normal returns a normally distributed float64 with the
+// provided mean and standard deviation.
+//
+// It panics if stddev < 0.
+func (r *Rand) Normal(mean, stddev float64) float64 {
+	if stddev < 0 {
+		panic("invalid argument to Normal")
+	}
+	return mean + stddev*r.NormFloat64()
+}
+
+// Normals fills dst with normally distributed float64s with the
+// provided mean and standard deviation.
+//
+// It panics if stddev < 0.
+func (r *Rand) Normals(dst []float64, mean, stddev float64) {
+	if stddev < 0 {
+		panic("invalid argument to Normals")
+	}
+	for i := range dst {
+		dst[i] = mean + stddev*r.NormFloat64()
+	}
+}
+
+// TruncatedNormal returns a normally distributed float64 with
+// the provided mean and standard deviation, conditioned to lie
+// in [lo, hi].
+//
+// It panics if stddev <= 0, lo >= hi, or mean is not finite.
+func TruncatedNormal(mean, stddev, lo, hi float64) float64 {
+	return defaultRand.TruncatedNormal(mean, stddev, lo, hi)
+}
+
+// TruncatedNormal returns a normally distributed float64 with
+// the provided mean and standard deviation, conditioned to lie
+// in [lo, hi].
+//
+// lo may be -Inf and hi may be +Inf. It uses the rejection
+// sampler from Robert, "Simulation of truncated normal
+// variables" (1995). The sampler proposes from a normal,
+// uniform, or translated exponential distribution, depending
+// on the interval, so intervals far in the tails take constant
+// expected time.
+//
+// It panics if stddev <= 0, lo >= hi, or mean is not finite.
+func (r *Rand) TruncatedNormal(mean, stddev, lo, hi float64) float64 {
+	if !(stddev > 0) || math.IsInf(stddev, 0) || !(lo < hi) ||
+		math.IsNaN(mean) || math.IsInf(mean, 0) {
+		panic("invalid argument to TruncatedNormal")
+	}
+	a := (lo - mean) / stddev
+	b := (hi - mean) / stddev
+	var z float64
+	if b <= 0 {
+		// Sample the mirror image in the right tail.
+		z = -r.stdTruncatedNormal(-b, -a)
+	} else {
+		z = r.stdTruncatedNormal(a, b)
+	}
+	x := mean + stddev*z
+	// Guard against rounding.
+	return math.Max(lo, math.Min(hi, x))
+}
+
+// stdTruncatedNormal samples the standard normal distribution
+// truncated to [a, b].
+//
+// a < b and b > 0.
+func (r *Rand) stdTruncatedNormal(a, b float64) float64 {
+	if a <= 0 {
+		// The interval contains the mode.
+		if b-a >= math.Sqrt(2*math.Pi) {
+			// Wide intervals accept a standard normal with
+			// probability at least 0.49.
+			for {
+				if z := r.NormFloat64(); z >= a && z <= b {
+					return z
+				}
+			}
+		}
+		// Narrow intervals accept a uniform proposal z with
+		// probability exp(-z^2/2).
+		for {
+			z := a + (b-a)*r.Float64()
+			if r.Float64() <= math.Exp(-z*z/2) {
+				return z
+			}
+		}
+	}
+
+	// The interval is in the right tail. The optimal rate for
+	// the translated exponential proposal is
+	// (a + sqrt(a^2+4)) / 2.
+	s := math.Sqrt(a*a + 4)
+	lambda := (a + s) / 2
+	if b-a > 2/(a+s)*math.Exp((a*a-a*s)/4+0.5) {
+		for {
+			z := a + r.ExpFloat64()/lambda
+			if z > b {
+				continue
+			}
+			if d := z - lambda; r.Float64() <= math.Exp(-d*d/2) {
+				return z
+			}
+		}
+	}
+	// The interval is narrow relative to the tail, so accept a
+	// uniform proposal z with probability exp((a^2-z^2)/2).
+	for {
+		z := a + (b-a)*r.Float64()
+		if r.Float64() <= math.Exp((a*a-z*z)/2) {
+			return z
+		}
+	}
+}
+
+// Exponential returns an exponentially distributed float64 with
+// the provided rate parameter (lambda) and mean 1/rate.
+//
+// It panics if rate <= 0.
+func Exponential(rate float64) float64 { return defaultRand.Exponential(rate) }
+
+// Exponential returns an exponentially distributed float64 with
+// the provided rate parameter (lambda) and mean 1/rate.
+//
+// It panics if rate <= 0.
+func (r *Rand) Exponential(rate float64) float64 {
+	if !(rate > 0) {
+		panic("invalid argument to Exponential")
+	}
+	return r.ExpFloat64() / rate
+}
+
+// Binomial returns the number of successes in n independent
+// trials that each succeed with probability p.
+//
+// It panics if n < 0 or p is not in [0, 1].
+func Binomial(n int, p float64) int { return defaultRand.Binomial(n, p) }
+
+// Binomial returns the number of successes in n independent
+// trials that each succeed with probability p.
+//
+// If n*min(p, 1-p) < 30 it uses inversion, which takes time
+// proportional to n*p. Otherwise, it uses the BTPE algorithm
+// from Kachitvichyanukul and Schmeiser, "Binomial Random
+// Variate Generation" (1988), which takes constant expected
+// time.
+//
+// It panics if n < 0 or p is not in [0, 1].
+func (r *Rand) Binomial(n int, p float64) int {
+	if n < 0 || !(p >= 0 && p <= 1) {
+		panic("invalid argument to Binomial")
+	}
+	if n == 0 || p == 0 {
+		return 0
+	}
+	if p == 1 {
+		return n
+	}
+	q := p
+	if q > 0.5 {
+		q = 1 - q
+	}
+	var k int
+	if float64(n)*q < 30 {
+		k = r.binomialInversion(n, q)
+	} else {
+		k = r.binomialBTPE(n, q)
+	}
+	if p > 0.5 {
+		k = n - k
+	}
+	return k
+}
+
+// binomialInversion samples Binomial(n, p) by sequential search
+// of the inverse CDF.
+//
+// p must be in (0, 0.5].
+func (r *Rand) binomialInversion(n int, p float64) int {
+	q := 1 - p
+	qn := math.Exp(float64(n) * math.Log(q))
+	np := float64(n) * p
+	bound := math.Min(float64(n), np+10*math.Sqrt(np*q+1))
+
+	k := 0
+	px := qn
+	u := r.Float64()
+	for u > px {
+		k++
+		if float64(k) > bound {
+			// Numerical error left u stranded in the far
+			// tail. Start over.
+			k = 0
+			px = qn
+			u = r.Float64()
+			continue
+		}
+		u -= px
+		px = float64(n-k+1) * p * px / (float64(k) * q)
+	}
+	return k
+}
+
+// binomialBTPE samples Binomial(n, p) using the BTPE algorithm.
+//
+// p must be in (0, 0.5] and n*p must be at least 30.
+func (r *Rand) binomialBTPE(n int, p float64) int {
+	// Step 0: setup.
+	var (
+		nf  = float64(n)
+		q   = 1 - p
+		nrq = nf * p * q
+		fm  = nf*p + p
+		m   = math.Floor(fm)
+		p1  = math.Floor(2.195*math.Sqrt(nrq)-4.6*q) + 0.5
+		xm  = m + 0.5
+		xl  = xm - p1
+		xr  = xm + p1
+		c   = 0.134 + 20.5/(15.3+m)
+	)
+	a := (fm - xl) / (fm - xl*p)
+	laml := a * (1 + a/2)
+	a = (xr - fm) / (xr * q)
+	lamr := a * (1 + a/2)
+	p2 := p1 * (1 + 2*c)
+	p3 := p2 + c/laml
+	p4 := p3 + c/lamr
+
+	for {
+		// Step 1: triangular region.
+		u := r.Float64() * p4
+		v := r.Float64()
+		if u <= p1 {
+			return int(math.Floor(xm - p1*v + u))
+		}
+
+		var y float64
+		switch {
+		case u <= p2:
+			// Step 2: parallelogram region.
+			x := xl + (u-p1)/c
+			v = v*c + 1 - math.Abs(m-x+0.5)/p1
+			if v > 1 {
+				continue
+			}
+			y = math.Floor(x)
+		case u <= p3:
+			// Step 3: left exponential tail.
+			y = math.Floor(xl + math.Log(v)/laml)
+			if y < 0 || v == 0 {
+				continue
+			}
+			v *= (u - p2) * laml
+		default:
+			// Step 4: right exponential tail.
+			y = math.Floor(xr - math.Log(v)/lamr)
+			if y > nf || v == 0 {
+				continue
+			}
+			v *= (u - p3) * lamr
+		}
+
+		// Step 5: acceptance/rejection comparison.
+		k := math.Abs(y - m)
+		if k <= 20 || k >= nrq/2-1 {
+			// Step 5.1: evaluate f(y)/f(m) recursively.
+			s := p / q
+			a := s * (nf + 1)
+			f := 1.0
+			if m < y {
+				for i := m + 1; i <= y; i++ {
+					f *= a/i - s
+				}
+			} else if m > y {
+				for i := y + 1; i <= m; i++ {
+					f /= a/i - s
+				}
+			}
+			if v <= f {
+				return int(y)
+			}
+			continue
+		}
+
+		// Step 5.2: squeeze using upper and lower bounds on
+		// log(f(y)/f(m)).
+		rho := (k / nrq) * ((k*(k/3+0.625)+1.0/6)/nrq + 0.5)
+		t := -k * k / (2 * nrq)
+		alpha := math.Log(v)
+		if alpha < t-rho {
+			return int(y)
+		}
+		if alpha > t+rho {
+			continue
+		}
+
+		// Step 5.3: final comparison using Stirling's formula.
+		x1 := y + 1
+		f1 := m + 1
+		z := nf + 1 - m
+		w := nf - y + 1
+		bound := xm*math.Log(f1/x1) +
+			(nf-m+0.5)*math.Log(z/w) +
+			(y-m)*math.Log(w*p/(x1*q)) +
+			stirlingCorrection(f1) +
+			stirlingCorrection(z) +
+			stirlingCorrection(x1) +
+			stirlingCorrection(w)
+		if alpha <= bound {
+			return int(y)
+		}
+	}
+}
+
+// stirlingCorrection returns the leading terms of the error in
+// Stirling's approximation of log(x!) used by BTPE.
+func stirlingCorrection(x float64) float64 {
+	x2 := x * x
+	return (13860 - (462-(132-(99-140/x2)/x2)/x2)/x2) / x / 166320
+}
+
+// maxPoissonLambda is the largest mean accepted by Poisson.
+//
+// Above it, a sample might not fit in an int.
+const maxPoissonLambda = math.MaxInt / 2
+
+// Poisson returns a Poisson distributed count with mean lambda.
+//
+// It panics if lambda < 0 or lambda > math.MaxInt/2.
+func Poisson(lambda float64) int { return defaultRand.Poisson(lambda) }
+
+// Poisson returns a Poisson distributed count with mean lambda.
+//
+// If lambda < 10 it uses Knuth's algorithm, which takes time
+// proportional to lambda. Otherwise, it uses the PTRS
+// transformed rejection method from Hörmann, "The transformed
+// rejection method for generating Poisson random variables"
+// (1993), which takes constant expected time.
+//
+// It panics if lambda < 0 or lambda > math.MaxInt/2, where the
+// result might not fit in an int.
+func (r *Rand) Poisson(lambda float64) int {
+	if !(lambda >= 0 && lambda <= maxPoissonLambda) {
+		panic("invalid argument to Poisson")
+	}
+	if lambda < 10 {
+		return r.poissonKnuth(lambda)
+	}
+	return r.poissonPTRS(lambda)
+}
+
+// poissonKnuth samples Poisson(lambda) by counting arrivals of a
+// unit rate Poisson process before time lambda.
+//
+// This is equivalent to Knuth's product of uniforms, but sums
+// the logarithms (exponential variates) instead.
+func (r *Rand) poissonKnuth(lambda float64) int {
+	k := 0
+	for t := r.ExpFloat64(); t <= lambda; t += r.ExpFloat64() {
+		k++
+	}
+	return k
+}
+
+// poissonPTRS samples Poisson(lambda) using the PTRS algorithm.
+//
+// lambda must be at least 10.
+func (r *Rand) poissonPTRS(lambda float64) int {
+	var (
+		slam     = math.Sqrt(lambda)
+		loglam   = math.Log(lambda)
+		b        = 0.931 + 2.53*slam
+		a        = -0.059 + 0.02483*b
+		invalpha = 1.1239 + 1.1328/(b-3.4)
+		vr       = 0.9277 - 3.6224/(b-2)
+	)
+	for {
+		u := r.Float64() - 0.5
+		v := r.Float64()
+		us := 0.5 - math.Abs(u)
+		k := math.Floor((2*a/us+b)*u + lambda + 0.43)
+		if us >= 0.07 && v <= vr {
+			return int(k)
+		}
+		if k < 0 || (us < 0.013 && v > us) {
+			continue
+		}
+		lg, _ := math.Lgamma(k + 1)
+		if math.Log(v)+math.Log(invalpha)-math.Log(a/(us*us)+b) <=
+			-lambda+k*loglam-lg {
+			return int(k)
+		}
+	}
+}
+
+// Geometric returns the number of independent trials, each
+// succeeding with probability p, up to and including the first
+// success.
+//
+// The result is in [1, math.MaxInt]. It panics if p is not in
+// (0, 1].
+func Geometric(p float64) int { return defaultRand.Geometric(p) }
+
+// Geometric returns the number of independent trials, each
+// succeeding with probability p, up to and including the first
+// success.
+//
+// It uses the inverse CDF ceil(log(U) / log(1-p)). The result is
+// in [1, math.MaxInt]; results that would overflow an int are
+// clamped to math.MaxInt. It panics if p is not in (0, 1].
+func (r *Rand) Geometric(p float64) int {
+	if !(p > 0 && p <= 1) {
+		panic("invalid argument to Geometric")
+	}
+	if p == 1 {
+		return 1
+	}
+	u := r.Float64()
+	for u == 0 {
+		u = r.Float64()
+	}
+	k := math.Ceil(math.Log(u) / math.Log1p(-p))
+	if k >= math.MaxInt {
+		return math.MaxInt
+	}
+	if k < 1 {
+		// Guard against rounding when p is tiny.
+		return 1
+	}
+	return int(k)
+}
+
+// NegativeBinomial returns the number of failures in
+// independent trials, each succeeding with probability p,
+// before the nth success.
+//
+// It panics if n <= 0 or p is not in (0, 1].
+func NegativeBinomial(n, p float64) int { return defaultRand.NegativeBinomial(n, p) }
+
+// NegativeBinomial returns the number of failures in
+// independent trials, each succeeding with probability p,
+// before the nth success.
+//
+// The mean is n*(1-p)/p and the variance is n*(1-p)/p^2. n
+// does not need to be an integer.
+//
+// It samples the gamma-Poisson mixture: a Poisson count whose
+// mean is drawn from Gamma(n, (1-p)/p). Means larger than
+// Poisson accepts are clamped, so extremely large counts are
+// capped near math.MaxInt/2 rather than overflowing.
+//
+// It panics if n <= 0 or p is not in (0, 1].
+func (r *Rand) NegativeBinomial(n, p float64) int {
+	if !(n > 0) || math.IsInf(n, 0) || !(p > 0 && p <= 1) {
+		panic("invalid argument to NegativeBinomial")
+	}
+	if p == 1 {
+		return 0
+	}
+	return r.Poisson(math.Min(r.Gamma(n, (1-p)/p), maxPoissonLambda))
+}
+
+// Hypergeometric returns the number of successes when drawing
+// n items without replacement from a population of N items, K
+// of which are successes.
+//
+// It panics unless 0 <= K <= N and 0 <= n <= N.
+func Hypergeometric(N, K, n int) int { return defaultRand.Hypergeometric(N, K, n) }
+
+// Hypergeometric returns the number of successes when drawing
+// n items without replacement from a population of N items, K
+// of which are successes.
+//
+// The result is in [max(0, n-(N-K)), min(n, K)]. The mean is
+// n*K/N.
+//
+// It uses inversion, searching outward from the mode with the
+// PMF's recurrence. This takes time proportional to the
+// standard deviation, which is at most sqrt(min(n, K))/2, and
+// does not depend on N.
+//
+// It panics unless 0 <= K <= N and 0 <= n <= N.
+func (r *Rand) Hypergeometric(N, K, n int) int {
+	if N < 0 || K < 0 || K > N || n < 0 || n > N {
+		panic("invalid argument to Hypergeometric")
+	}
+	// Use symmetry so that K and n are both at most N/2.
+	if K > N/2 {
+		return n - r.Hypergeometric(N, N-K, n)
+	}
+	if n > N/2 {
+		return K - r.Hypergeometric(N, K, N-n)
+	}
+	if K == 0 || n == 0 {
+		return 0
+	}
+
+	bad := N - K
+	lo, hi := 0, n // n <= N/2 <= bad
+	if K < hi {
+		hi = K
+	}
+	mode := int((float64(n) + 1) * (float64(K) + 1) / (float64(N) + 2))
+	if mode < lo {
+		mode = lo
+	} else if mode > hi {
+		mode = hi
+	}
+	pmode := math.Exp(lchoose(K, mode) + lchoose(bad, n-mode) - lchoose(N, n))
+
+	for {
+		u := r.Float64() - pmode
+		if u < 0 {
+			return mode
+		}
+		up, pu := mode, pmode
+		down, pd := mode, pmode
+		for up < hi || down > lo {
+			if up < hi {
+				// P(k+1)/P(k) = (K-k)(n-k) / ((k+1)(bad-n+k+1))
+				k := float64(up)
+				pu *= (float64(K) - k) * (float64(n) - k) /
+					((k + 1) * (float64(bad-n) + k + 1))
+				up++
+				if u -= pu; u < 0 {
+					return up
+				}
+			}
+			if down > lo {
+				// P(k-1)/P(k) = k(bad-n+k) / ((K-k+1)(n-k+1))
+				k := float64(down)
+				pd *= k * (float64(bad-n) + k) /
+					((float64(K) - k + 1) * (float64(n) - k + 1))
+				down--
+				if u -= pd; u < 0 {
+					return down
+				}
+			}
+		}
+		// Rounding left u just above zero after the entire
+		// support. Try again.
+	}
+}
+
+// lchoose returns log(n choose k).
+func lchoose(n, k int) float64 {
+	a, _ := math.Lgamma(float64(n + 1))
+	b, _ := math.Lgamma(float64(k + 1))
+	c, _ := math.Lgamma(float64(n - k + 1))
+	return a - b - c
+}
+
+// Gamma returns a gamma distributed float64 with the provided
+// shape (k) and scale (theta) parameters.
+//
+// It panics if shape <= 0 or scale <= 0.
+func Gamma(shape, scale float64) float64 { return defaultRand.Gamma(shape, scale) }
+
+// Gamma returns a gamma distributed float64 with the provided
+// shape (k) and scale (theta) parameters.
+//
+// The mean is shape*scale and the variance is shape*scale^2.
+//
+// It uses the method from Marsaglia and Tsang, "A Simple Method
+// for Generating Gamma Variables" (2000). For shape < 1 it
+// samples Gamma(shape+1) and multiplies by U^(1/shape).
+//
+// It panics if shape <= 0 or scale <= 0.
+func (r *Rand) Gamma(shape, scale float64) float64 {
+	if !(shape > 0) || !(scale > 0) {
+		panic("invalid argument to Gamma")
+	}
+	if shape < 1 {
+		return r.stdGamma(shape+1) * math.Pow(r.Float64(), 1/shape) * scale
+	}
+	return r.stdGamma(shape) * scale
+}
+
+// stdGamma samples Gamma(shape, 1) for shape >= 1.
+func (r *Rand) stdGamma(shape float64) float64 {
+	d := shape - 1.0/3
+	return r.marsagliaTsang(d, 1/math.Sqrt(9*d))
+}
+
+// marsagliaTsang samples Gamma(d+1/3, 1) given the constants
+// d = shape - 1/3 and c = 1/sqrt(9*d).
+func (r *Rand) marsagliaTsang(d, c float64) float64 {
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		x2 := x * x
+		if u < 1-0.0331*x2*x2 {
+			return d * v
+		}
+		if math.Log(u) < 0.5*x2+d*(1-v+math.Log(v)) {
+			return d * v
+		}
+	}
+}
+
+// Beta returns a beta distributed float64 in (0, 1) with the
+// provided shape parameters.
+//
+// It panics if alpha <= 0 or beta <= 0.
+func Beta(alpha, beta float64) float64 { return defaultRand.Beta(alpha, beta) }
+
+// Beta returns a beta distributed float64 in (0, 1) with the
+// provided shape parameters.
+//
+// The result is X/(X+Y) where X ~ Gamma(alpha, 1) and
+// Y ~ Gamma(beta, 1), computed in log space so that small
+// parameters do not underflow. Results that round to 0 or 1 are
+// clamped to the nearest representable float64 strictly inside
+// (0, 1).
+//
+// It panics if alpha <= 0 or beta <= 0.
+func (r *Rand) Beta(alpha, beta float64) float64 {
+	if !(alpha > 0) || !(beta > 0) {
+		panic("invalid argument to Beta")
+	}
+	var x float64
+	if alpha >= 1 && beta >= 1 {
+		a := r.stdGamma(alpha)
+		b := r.stdGamma(beta)
+		x = a / (a + b)
+	} else {
+		// 1/(1+Y/X) = X/(X+Y).
+		d := r.logStdGamma(beta) - r.logStdGamma(alpha)
+		x = 1 / (1 + math.Exp(d))
+	}
+	switch {
+	case x <= 0:
+		return math.SmallestNonzeroFloat64
+	case x >= 1:
+		return 1 - 0x1p-53
+	}
+	return x
+}
+
+// logStdGamma returns the logarithm of a Gamma(shape, 1)
+// variate.
+//
+// Unlike log(Gamma(shape, 1)), it does not underflow when shape
+// is small.
+func (r *Rand) logStdGamma(shape float64) float64 {
+	if shape >= 1 {
+		return math.Log(r.stdGamma(shape))
+	}
+	u := r.Float64()
+	for u == 0 {
+		u = r.Float64()
+	}
+	return math.Log(r.stdGamma(shape+1)) + math.Log(u)/shape
+}
+
+// Bool returns true or false with equal probability.
+func Bool() bool { return defaultRand.Bool() }
+
+// Bool returns true or false with equal probability.
+func (r *Rand) Bool() bool {
+	return r.Uint64()>>63 == 1
+}
+
+// Bernoulli returns true with probability p.
+//
+// It panics if p is not in [0, 1].
+func Bernoulli(p float64) bool { return defaultRand.Bernoulli(p) }
+
+// Bernoulli returns true with probability p.
+//
+// It panics if p is not in [0, 1].
+func (r *Rand) Bernoulli(p float64) bool {
+	if !(p >= 0 && p <= 1) {
+		panic("invalid argument to Bernoulli")
+	}
+	// Float64 is in [0, 1), so p == 0 is always false and
+	// p == 1 is always true.
+	return r.Float64() < p
+}
+
+// SubsetMask returns n independent Bernoulli(p) trials.
+//
+// It panics if n < 0 or p is not in [0, 1].
+func SubsetMask(n int, p float64) []bool { return defaultRand.SubsetMask(n, p) }
+
+// SubsetMask returns n independent Bernoulli(p) trials.
+//
+// Element i is true with probability p. When p is 0.5, each
+// element takes a single bit from Bits. Otherwise, each element
+// is Bernoulli(p).
+//
+// It panics if n < 0 or p is not in [0, 1].
+func (r *Rand) SubsetMask(n int, p float64) []bool {
+	if n < 0 || !(p >= 0 && p <= 1) {
+		panic("invalid argument to SubsetMask")
+	}
+	mask := make([]bool, n)
+	switch p {
+	case 0:
+	case 1:
+		for i := range mask {
+			mask[i] = true
+		}
+	case 0.5:
+		words := r.Bits(n)
+		for i := range mask {
+			mask[i] = words[i/64]>>(i%64)&1 == 1
+		}
+		for i := range words {
+			words[i] = 0
+		}
+	default:
+		for i := range mask {
+			mask[i] = r.Float64() < p
+		}
+	}
+	return mask
+}
+
+// Dirichlet returns a random probability vector drawn from the
+// Dirichlet distribution with concentration parameters alpha.
+//
+// It panics if alpha is empty or any element is not positive.
+func Dirichlet(alpha []float64) []float64 { return defaultRand.Dirichlet(alpha) }
+
+// Dirichlet returns a random probability vector drawn from the
+// Dirichlet distribution with concentration parameters alpha.
+//
+// The result has the same length as alpha, its elements are in
+// [0, 1], and they sum to 1 within rounding error. Each element
+// is Gamma(alpha[i], 1) divided by the sum of all of them, which
+// is computed in log space so that small parameters do not
+// underflow.
+//
+// It panics if alpha is empty or any element is not positive.
+func (r *Rand) Dirichlet(alpha []float64) []float64 {
+	if len(alpha) == 0 {
+		panic("invalid argument to Dirichlet")
+	}
+	for _, a := range alpha {
+		if !(a > 0) || math.IsInf(a, 0) {
+			panic("invalid argument to Dirichlet")
+		}
+	}
+	x := make([]float64, len(alpha))
+	max := math.Inf(-1)
+	for i, a := range alpha {
+		x[i] = r.logStdGamma(a)
+		if x[i] > max {
+			max = x[i]
+		}
+	}
+	var sum float64
+	for i := range x {
+		x[i] = math.Exp(x[i] - max)
+		sum += x[i]
+	}
+	for i := range x {
+		x[i] /= sum
+	}
+	return x
+}
+
+// Triangular returns a float64 from the triangular
+// distribution with lower limit min, upper limit max, and mode
+// mode.
+//
+// It panics unless min <= mode <= max.
+func Triangular(min, mode, max float64) float64 { return defaultRand.Triangular(min, mode, max) }
+
+// Triangular returns a float64 from the triangular
+// distribution with lower limit min, upper limit max, and mode
+// mode.
+//
+// It uses the inverse CDF. The result is in [min, max].
+//
+// It panics unless min <= mode <= max.
+func (r *Rand) Triangular(min, mode, max float64) float64 {
+	if !(min <= mode && mode <= max) ||
+		math.IsInf(min, 0) || math.IsInf(max, 0) {
+		panic("invalid argument to Triangular")
+	}
+	if min == max {
+		return min
+	}
+	u := r.Float64()
+	span := max - min
+	var x float64
+	if u < (mode-min)/span {
+		x = min + math.Sqrt(u*span*(mode-min))
+	} else {
+		x = max - math.Sqrt((1-u)*span*(max-mode))
+	}
+	// Guard against rounding.
+	return math.Min(math.Max(x, min), max)
+}
+
+// Multinomial returns the number of times each outcome occurs
+// in n independent trials where outcome i occurs with
+// probability p[i].
+//
+// It panics if n < 0, p is empty, any element of p is negative,
+// or p does not sum to 1.
+func Multinomial(n int, p []float64) []int { return defaultRand.Multinomial(n, p) }
+
+// Multinomial returns the number of times each outcome occurs
+// in n independent trials where outcome i occurs with
+// probability p[i].
+//
+// The result has the same length as p and sums to n. Each
+// count is drawn from a Binomial distribution conditioned on
+// the counts before it, so it takes time proportional to
+// len(p), not n.
+//
+// It panics if n < 0, p is empty, any element of p is negative,
+// or p does not sum to 1 within 1e-9.
+func (r *Rand) Multinomial(n int, p []float64) []int {
+	if n < 0 || len(p) == 0 {
+		panic("invalid argument to Multinomial")
+	}
+	var sum float64
+	for _, v := range p {
+		if !(v >= 0) || math.IsInf(v, 0) {
+			panic("invalid argument to Multinomial")
+		}
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		panic("invalid argument to Multinomial")
+	}
+
+	counts := make([]int, len(p))
+	rest := sum // the probability mass of p[i:]
+	for i, v := range p[:len(p)-1] {
+		if n == 0 {
+			break
+		}
+		q := 1.0
+		if v < rest {
+			q = v / rest
+		}
+		k := r.Binomial(n, q)
+		counts[i] = k
+		n -= k
+		rest -= v
+	}
+	counts[len(counts)-1] = n
+	return counts
+}
+
+// Cauchy returns a float64 from the Cauchy distribution with
+// location x0 and scale gamma.
+//
+// It panics if gamma <= 0.
+func Cauchy(x0, gamma float64) float64 { return defaultRand.Cauchy(x0, gamma) }
+
+// Cauchy returns a float64 from the Cauchy distribution with
+// location x0 and scale gamma.
+//
+// It uses the inverse CDF x0 + gamma*tan(pi*(U-0.5)) with U in
+// (0, 1), so the result is always finite. The distribution has
+// no mean; its median is x0.
+//
+// It panics if gamma <= 0.
+func (r *Rand) Cauchy(x0, gamma float64) float64 {
+	if !(gamma > 0) {
+		panic("invalid argument to Cauchy")
+	}
+	return x0 + gamma*math.Tan(math.Pi*(r.float64Open()-0.5))
+}
+
+// LogNormal returns a float64 whose natural logarithm is
+// normally distributed with mean mu and standard deviation
+// sigma.
+//
+// It panics if sigma < 0.
+func LogNormal(mu, sigma float64) float64 { return defaultRand.LogNormal(mu, sigma) }
+
+// LogNormal returns a float64 whose natural logarithm is
+// normally distributed with mean mu and standard deviation
+// sigma.
+//
+// The result is always positive: results that would underflow
+// to zero are clamped to math.SmallestNonzeroFloat64.
+//
+// It panics if sigma < 0.
+func (r *Rand) LogNormal(mu, sigma float64) float64 {
+	if !(sigma >= 0) {
+		panic("invalid argument to LogNormal")
+	}
+	x := math.Exp(mu + sigma*r.NormFloat64())
+	if x == 0 {
+		return math.SmallestNonzeroFloat64
+	}
+	return x
+}
+
+// Pareto returns a float64 from the Pareto distribution with
+// scale (minimum) xm and shape alpha.
+//
+// It panics if xm <= 0 or alpha <= 0.
+func Pareto(xm, alpha float64) float64 { return defaultRand.Pareto(xm, alpha) }
+
+// Pareto returns a float64 from the Pareto distribution with
+// scale (minimum) xm and shape alpha.
+//
+// It uses the inverse CDF xm / U^(1/alpha) with U in (0, 1). The
+// result is always >= xm and may be +Inf if alpha is very small.
+//
+// It panics if xm <= 0 or alpha <= 0.
+func (r *Rand) Pareto(xm, alpha float64) float64 {
+	if !(xm > 0) || !(alpha > 0) {
+		panic("invalid argument to Pareto")
+	}
+	x := xm / math.Pow(r.float64Open(), 1/alpha)
+	return math.Max(x, xm)
+}
+
+// Weibull returns a float64 from the Weibull distribution with
+// scale lambda and shape k.
+//
+// It panics if lambda <= 0 or k <= 0.
+func Weibull(lambda, k float64) float64 { return defaultRand.Weibull(lambda, k) }
+
+// Weibull returns a float64 from the Weibull distribution with
+// scale lambda and shape k.
+//
+// It uses the inverse CDF lambda * (-ln U)^(1/k) with U in
+// (0, 1), so the logarithm is always finite.
+//
+// It panics if lambda <= 0 or k <= 0.
+func (r *Rand) Weibull(lambda, k float64) float64 {
+	if !(lambda > 0) || !(k > 0) {
+		panic("invalid argument to Weibull")
+	}
+	return lambda * math.Pow(-math.Log(r.float64Open()), 1/k)
+}
+
+// Laplace returns a float64 from the Laplace (double
+// exponential) distribution with location mu and scale b.
+//
+// It panics if b <= 0.
+func Laplace(mu, b float64) float64 { return defaultRand.Laplace(mu, b) }
+
+// Laplace returns a float64 from the Laplace (double
+// exponential) distribution with location mu and scale b.
+//
+// It uses the inverse CDF mu - b*sign(u)*ln(1-2|u|) with u in
+// (-0.5, 0.5). Because U is drawn from (0, 1), 1-2|u| is at
+// least 2^-52 and the result is always finite.
+//
+// It panics if b <= 0.
+func (r *Rand) Laplace(mu, b float64) float64 {
+	if !(b > 0) {
+		panic("invalid argument to Laplace")
+	}
+	u := r.float64Open() - 0.5
+	if u < 0 {
+		return mu + b*math.Log1p(2*u)
+	}
+	return mu - b*math.Log1p(-2*u)
+}
+
+// ChiSquared returns a float64 from the chi-squared
+// distribution with k degrees of freedom.
+//
+// It panics if k <= 0.
+func ChiSquared(k float64) float64 { return defaultRand.ChiSquared(k) }
+
+// ChiSquared returns a float64 from the chi-squared
+// distribution with k degrees of freedom.
+//
+// It samples Gamma(k/2, 2). The mean is k and the variance is
+// 2k.
+//
+// It panics if k <= 0.
+func (r *Rand) ChiSquared(k float64) float64 {
+	if !(k > 0) {
+		panic("invalid argument to ChiSquared")
+	}
+	return r.Gamma(k/2, 2)
+}
+
+// StudentsT returns a float64 from Student's t-distribution
+// with nu degrees of freedom.
+//
+// It panics if nu <= 0.
+func StudentsT(nu float64) float64 { return defaultRand.StudentsT(nu) }
+
+// StudentsT returns a float64 from Student's t-distribution
+// with nu degrees of freedom.
+//
+// It samples Z / sqrt(V/nu), where Z is standard normal and V
+// is ChiSquared(nu). For nu > 2 the variance is nu/(nu-2). In
+// the rare case that V underflows to zero, which only happens
+// for very small nu, V is redrawn so that the result is finite.
+//
+// It panics if nu <= 0.
+func (r *Rand) StudentsT(nu float64) float64 {
+	if !(nu > 0) {
+		panic("invalid argument to StudentsT")
+	}
+	z := r.NormFloat64()
+	for {
+		if v := r.ChiSquared(nu); v > 0 {
+			return z / math.Sqrt(v/nu)
+		}
+	}
+}
+
+// Rayleigh returns a float64 from the Rayleigh distribution
+// with scale sigma.
+//
+// It panics if sigma <= 0.
+func Rayleigh(sigma float64) float64 { return defaultRand.Rayleigh(sigma) }
+
+// Rayleigh returns a float64 from the Rayleigh distribution
+// with scale sigma.
+//
+// It uses the inverse CDF sigma*sqrt(-2*ln(U)) with U in
+// (0, 1], so the result is always finite and non-negative. The
+// mean is sigma*sqrt(pi/2).
+//
+// It panics if sigma <= 0.
+func (r *Rand) Rayleigh(sigma float64) float64 {
+	if !(sigma > 0) {
+		panic("invalid argument to Rayleigh")
+	}
+	return sigma * math.Sqrt(-2*math.Log(r.Float64Pos()))
+}
+
+// LogUniform returns a float64 in [min, max) whose logarithm
+// is uniformly distributed.
+//
+// It panics if min <= 0, min >= max, or max is infinite.
+func LogUniform(min, max float64) float64 { return defaultRand.LogUniform(min, max) }
+
+// LogUniform returns a float64 in [min, max) whose logarithm
+// is uniformly distributed.
+//
+// This is the reciprocal distribution: each decade between min
+// and max is equally likely. It computes
+// exp(Float64Range(ln(min), ln(max))) and clamps the result to
+// [min, max) in case of rounding error.
+//
+// It panics if min <= 0, min >= max, or max is infinite.
+func (r *Rand) LogUniform(min, max float64) float64 {
+	if !(min > 0) || !(min < max) || math.IsInf(max, 0) {
+		panic("invalid argument to LogUniform")
+	}
+	x := math.Exp(r.Float64Range(math.Log(min), math.Log(max)))
+	if x >= max {
+		return math.Nextafter(max, min)
+	}
+	if x < min {
+		return min
+	}
+	return x
+}
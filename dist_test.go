@@ -0,0 +1,1001 @@
+package saferand
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+// checkMoments fails the test if the sample mean or variance of
+// samples is too far from mean or variance.
+//
+// The tolerance is six standard errors, estimated from the
+// samples themselves.
+func checkMoments(t *testing.T, samples []float64, mean, variance float64) {
+	t.Helper()
+	n := float64(len(samples))
+	var sum float64
+	for _, x := range samples {
+		sum += x
+	}
+	m := sum / n
+	var m2, m4 float64
+	for _, x := range samples {
+		d := (x - m) * (x - m)
+		m2 += d
+		m4 += d * d
+	}
+	m2 /= n
+	m4 /= n
+	if se := math.Sqrt(m2 / n); math.Abs(m-mean) > 6*se {
+		t.Errorf("mean: got %g, expected %g (± %g)", m, mean, 6*se)
+	}
+	if se := math.Sqrt((m4 - m2*m2) / n); math.Abs(m2-variance) > 6*se {
+		t.Errorf("variance: got %g, expected %g (± %g)", m2, variance, 6*se)
+	}
+}
+
+// checkPanics fails the test if fn does not panic.
+func checkPanics(t *testing.T, name string, fn func()) {
+	t.Helper()
+	defer func() {
+		t.Helper()
+		if recover() == nil {
+			t.Errorf("%s: expected a panic", name)
+		}
+	}()
+	fn()
+}
+
+func TestNormal(t *testing.T) {
+	for _, tc := range []struct {
+		mean, stddev float64
+	}{
+		{0, 1},
+		{-3, 0.5},
+		{100, 25},
+		{7, 0},
+	} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			samples[i] = Normal(tc.mean, tc.stddev)
+		}
+		checkMoments(t, samples, tc.mean, tc.stddev*tc.stddev)
+
+		Normals(samples, tc.mean, tc.stddev)
+		checkMoments(t, samples, tc.mean, tc.stddev*tc.stddev)
+	}
+	checkPanics(t, "Normal", func() { Normal(0, -1) })
+	checkPanics(t, "Normals", func() { Normals(nil, 0, -1) })
+}
+
+func TestTruncatedNormal(t *testing.T) {
+	phi := func(x float64) float64 {
+		if math.IsInf(x, 0) {
+			return 0
+		}
+		return math.Exp(-x*x/2) / math.Sqrt(2*math.Pi)
+	}
+	for _, tc := range []struct {
+		mean, stddev, lo, hi float64
+	}{
+		{0, 1, -1, 1},
+		{0, 1, math.Inf(-1), math.Inf(1)},
+		{10, 2, 4, 16},
+		{0, 1, -0.1, 0.2},
+		{0, 1, 0.5, 3},
+		{0, 1, 5, 6},
+		{0, 1, 8, 8.01},
+		{0, 1, 3, math.Inf(1)},
+		{0, 1, math.Inf(-1), -4},
+		{3, 0.5, -10, -5},
+	} {
+		// The moments of the standard normal truncated to [a, b].
+		a := (tc.lo - tc.mean) / tc.stddev
+		b := (tc.hi - tc.mean) / tc.stddev
+		var z float64
+		if b <= 0 {
+			z = (math.Erfc(-b/math.Sqrt2) - math.Erfc(-a/math.Sqrt2)) / 2
+		} else {
+			z = (math.Erfc(a/math.Sqrt2) - math.Erfc(b/math.Sqrt2)) / 2
+		}
+		am, bm := a*phi(a), b*phi(b)
+		if math.IsInf(a, 0) {
+			am = 0
+		}
+		if math.IsInf(b, 0) {
+			bm = 0
+		}
+		mu := (phi(a) - phi(b)) / z
+		v := 1 + (am-bm)/z - mu*mu
+
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := TruncatedNormal(tc.mean, tc.stddev, tc.lo, tc.hi)
+			if !(x >= tc.lo && x <= tc.hi) {
+				t.Fatalf("TruncatedNormal(%g, %g, %g, %g): out of range: %g",
+					tc.mean, tc.stddev, tc.lo, tc.hi, x)
+			}
+			samples[i] = x
+		}
+		checkMoments(t, samples, tc.mean+tc.stddev*mu, tc.stddev*tc.stddev*v)
+	}
+
+	for _, tc := range [][4]float64{
+		{0, 0, -1, 1},
+		{0, -1, -1, 1},
+		{0, math.NaN(), -1, 1},
+		{0, math.Inf(1), -1, 1},
+		{0, 1, 1, 1},
+		{0, 1, 2, 1},
+		{0, 1, math.NaN(), 1},
+		{math.NaN(), 1, -1, 1},
+		{math.Inf(1), 1, -1, 1},
+	} {
+		checkPanics(t, "TruncatedNormal", func() { TruncatedNormal(tc[0], tc[1], tc[2], tc[3]) })
+	}
+}
+
+func TestExponential(t *testing.T) {
+	for _, rate := range []float64{0.01, 0.5, 1, 3, 250} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			samples[i] = Exponential(rate)
+			if samples[i] < 0 {
+				t.Fatalf("%g: negative sample: %g", rate, samples[i])
+			}
+		}
+		checkMoments(t, samples, 1/rate, 1/(rate*rate))
+	}
+	checkPanics(t, "Exponential(0)", func() { Exponential(0) })
+	checkPanics(t, "Exponential(-1)", func() { Exponential(-1) })
+	checkPanics(t, "Exponential(NaN)", func() { Exponential(math.NaN()) })
+}
+
+// checkPMF fails the test if the integers produced by sample
+// are unlikely to have come from the distribution pmf.
+//
+// Outcomes outside [lo, hi] are pooled into a single bucket.
+func checkPMF(t *testing.T, nsamples int, sample func() int, pmf func(k int) float64, lo, hi int) {
+	t.Helper()
+	counts := make([]int, hi-lo+2)
+	probs := make([]float64, len(counts))
+	rest := 1.0
+	for k := lo; k <= hi; k++ {
+		probs[k-lo] = pmf(k)
+		rest -= probs[k-lo]
+	}
+	probs[len(probs)-1] = math.Max(rest, 0)
+	for i := 0; i < nsamples; i++ {
+		k := sample()
+		if k < lo || k > hi {
+			counts[len(counts)-1]++
+		} else {
+			counts[k-lo]++
+		}
+	}
+	checkChiSquare(t, counts, probs)
+}
+
+func binomialPMF(n int, p float64) func(k int) float64 {
+	return func(k int) float64 {
+		if k < 0 || k > n {
+			return 0
+		}
+		return math.Exp(lchoose(n, k) +
+			float64(k)*math.Log(p) +
+			float64(n-k)*math.Log1p(-p))
+	}
+}
+
+func TestBinomial(t *testing.T) {
+	for _, tc := range []struct {
+		n int
+		p float64
+	}{
+		{1, 0.5},
+		{10, 0.3},
+		{50, 0.9},
+		{100, 0.4},   // BTPE
+		{1000, 0.05}, // BTPE
+		{5000, 0.97}, // BTPE
+		{1e7, 0.5},   // BTPE
+	} {
+		n, p := tc.n, tc.p
+		samples := make([]float64, 100000)
+		for i := range samples {
+			k := Binomial(n, p)
+			if k < 0 || k > n {
+				t.Fatalf("Binomial(%d, %g): out of range: %d", n, p, k)
+			}
+			samples[i] = float64(k)
+		}
+		mean := float64(n) * p
+		checkMoments(t, samples, mean, mean*(1-p))
+
+		sd := math.Sqrt(mean * (1 - p))
+		lo := int(math.Max(0, mean-4*sd))
+		hi := int(math.Min(float64(n), mean+4*sd))
+		if hi-lo > 200 {
+			continue
+		}
+		checkPMF(t, 200000, func() int { return Binomial(n, p) },
+			binomialPMF(n, p), lo, hi)
+	}
+
+	for _, tc := range []struct {
+		n    int
+		p    float64
+		want int
+	}{
+		{0, 0.5, 0},
+		{10, 0, 0},
+		{10, 1, 10},
+	} {
+		if got := Binomial(tc.n, tc.p); got != tc.want {
+			t.Errorf("Binomial(%d, %g): got %d, expected %d",
+				tc.n, tc.p, got, tc.want)
+		}
+	}
+
+	checkPanics(t, "Binomial(-1, 0.5)", func() { Binomial(-1, 0.5) })
+	checkPanics(t, "Binomial(1, -0.1)", func() { Binomial(1, -0.1) })
+	checkPanics(t, "Binomial(1, 1.1)", func() { Binomial(1, 1.1) })
+	checkPanics(t, "Binomial(1, NaN)", func() { Binomial(1, math.NaN()) })
+}
+
+func BenchmarkBinomial(b *testing.B) {
+	for n := b.N; n > 0; n-- {
+		Binomial(1e6, 0.3)
+	}
+}
+
+func poissonPMF(lambda float64) func(k int) float64 {
+	return func(k int) float64 {
+		if k < 0 {
+			return 0
+		}
+		lg, _ := math.Lgamma(float64(k + 1))
+		return math.Exp(float64(k)*math.Log(lambda) - lambda - lg)
+	}
+}
+
+func TestPoisson(t *testing.T) {
+	for _, lambda := range []float64{0.1, 1, 9.5, 10, 42, 1e4, 1e7} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			k := Poisson(lambda)
+			if k < 0 {
+				t.Fatalf("Poisson(%g): negative: %d", lambda, k)
+			}
+			samples[i] = float64(k)
+		}
+		checkMoments(t, samples, lambda, lambda)
+
+		sd := math.Sqrt(lambda)
+		lo := int(math.Max(0, lambda-4*sd))
+		hi := int(lambda + 4*sd)
+		if hi-lo > 200 {
+			continue
+		}
+		checkPMF(t, 200000, func() int { return Poisson(lambda) },
+			poissonPMF(lambda), lo, hi)
+	}
+	if got := Poisson(0); got != 0 {
+		t.Errorf("Poisson(0): got %d, expected 0", got)
+	}
+	checkPanics(t, "Poisson(-1)", func() { Poisson(-1) })
+	checkPanics(t, "Poisson(NaN)", func() { Poisson(math.NaN()) })
+	checkPanics(t, "Poisson(+Inf)", func() { Poisson(math.Inf(1)) })
+	checkPanics(t, "Poisson(1e300)", func() { Poisson(1e300) })
+	checkPanics(t, "Poisson(MaxInt)", func() { Poisson(math.MaxInt) })
+
+	// The largest mean does not overflow.
+	for i := 0; i < 1000; i++ {
+		if k := Poisson(maxPoissonLambda); k < maxPoissonLambda/2 {
+			t.Fatalf("Poisson(%d): got %d", maxPoissonLambda, k)
+		}
+	}
+}
+
+func BenchmarkPoisson(b *testing.B) {
+	for n := b.N; n > 0; n-- {
+		Poisson(1e4)
+	}
+}
+
+func TestGeometric(t *testing.T) {
+	for _, p := range []float64{1e-4, 0.01, 0.2, 0.5, 0.99} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			k := Geometric(p)
+			if k < 1 {
+				t.Fatalf("Geometric(%g): got %d", p, k)
+			}
+			samples[i] = float64(k)
+		}
+		checkMoments(t, samples, 1/p, (1-p)/(p*p))
+	}
+
+	p := 0.3
+	checkPMF(t, 100000, func() int { return Geometric(p) },
+		func(k int) float64 {
+			return math.Pow(1-p, float64(k-1)) * p
+		}, 1, 25)
+
+	if got := Geometric(1); got != 1 {
+		t.Errorf("Geometric(1): got %d, expected 1", got)
+	}
+	if got := Geometric(math.SmallestNonzeroFloat64); got < 1 {
+		t.Errorf("Geometric(tiny): got %d", got)
+	}
+
+	checkPanics(t, "Geometric(0)", func() { Geometric(0) })
+	checkPanics(t, "Geometric(1.1)", func() { Geometric(1.1) })
+	checkPanics(t, "Geometric(NaN)", func() { Geometric(math.NaN()) })
+}
+
+func TestNegativeBinomial(t *testing.T) {
+	for _, tc := range []struct {
+		n, p float64
+	}{
+		{1, 0.5},
+		{3, 0.2},
+		{0.5, 0.7},
+		{20, 0.9},
+		{100, 0.01},
+	} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			k := NegativeBinomial(tc.n, tc.p)
+			if k < 0 {
+				t.Fatalf("NegativeBinomial(%g, %g): got %d", tc.n, tc.p, k)
+			}
+			samples[i] = float64(k)
+		}
+		q := 1 - tc.p
+		checkMoments(t, samples, tc.n*q/tc.p, tc.n*q/(tc.p*tc.p))
+	}
+
+	n, p := 2.5, 0.4
+	checkPMF(t, 100000, func() int { return NegativeBinomial(n, p) },
+		func(k int) float64 {
+			a, _ := math.Lgamma(float64(k) + n)
+			b, _ := math.Lgamma(float64(k + 1))
+			c, _ := math.Lgamma(n)
+			return math.Exp(a - b - c + n*math.Log(p) + float64(k)*math.Log1p(-p))
+		}, 0, 15)
+
+	if got := NegativeBinomial(5, 1); got != 0 {
+		t.Errorf("NegativeBinomial(5, 1): got %d, expected 0", got)
+	}
+	// Huge means are clamped instead of overflowing.
+	for i := 0; i < 100; i++ {
+		if k := NegativeBinomial(1e300, 0.5); k < 0 {
+			t.Fatalf("NegativeBinomial(1e300, 0.5): got %d", k)
+		}
+	}
+
+	checkPanics(t, "NegativeBinomial(0, 0.5)", func() { NegativeBinomial(0, 0.5) })
+	checkPanics(t, "NegativeBinomial(Inf, 0.5)", func() { NegativeBinomial(math.Inf(1), 0.5) })
+	checkPanics(t, "NegativeBinomial(NaN, 0.5)", func() { NegativeBinomial(math.NaN(), 0.5) })
+	checkPanics(t, "NegativeBinomial(1, 0)", func() { NegativeBinomial(1, 0) })
+	checkPanics(t, "NegativeBinomial(1, 1.1)", func() { NegativeBinomial(1, 1.1) })
+	checkPanics(t, "NegativeBinomial(1, NaN)", func() { NegativeBinomial(1, math.NaN()) })
+}
+
+func TestHypergeometric(t *testing.T) {
+	for _, tc := range []struct {
+		N, K, n int
+	}{
+		{10, 3, 4},
+		{50, 25, 25},
+		{100, 90, 30},
+		{100, 10, 95},
+		{1000, 500, 10},
+		{1e9, 3e8, 1e6},
+		{1e9, 1e3, 5e8},
+	} {
+		lo, hi := tc.n-(tc.N-tc.K), tc.n
+		if lo < 0 {
+			lo = 0
+		}
+		if tc.K < hi {
+			hi = tc.K
+		}
+		samples := make([]float64, 20000)
+		for i := range samples {
+			k := Hypergeometric(tc.N, tc.K, tc.n)
+			if k < lo || k > hi {
+				t.Fatalf("Hypergeometric(%d, %d, %d): %d not in [%d, %d]",
+					tc.N, tc.K, tc.n, k, lo, hi)
+			}
+			samples[i] = float64(k)
+		}
+		N, K, n := float64(tc.N), float64(tc.K), float64(tc.n)
+		checkMoments(t, samples, n*K/N, n*K/N*(N-K)/N*(N-n)/(N-1))
+	}
+
+	N, K, n := 40, 15, 12
+	checkPMF(t, 100000, func() int { return Hypergeometric(N, K, n) },
+		func(k int) float64 {
+			return math.Exp(lchoose(K, k) + lchoose(N-K, n-k) - lchoose(N, n))
+		}, 0, n)
+
+	for _, tc := range [][3]int{
+		{0, 0, 0},
+		{5, 0, 3},
+		{5, 5, 3},
+		{5, 2, 0},
+		{5, 2, 5},
+	} {
+		want := 0
+		if tc[1] == tc[0] {
+			want = tc[2]
+		} else if tc[2] == tc[0] {
+			want = tc[1]
+		}
+		if got := Hypergeometric(tc[0], tc[1], tc[2]); got != want {
+			t.Errorf("Hypergeometric(%d, %d, %d): got %d, expected %d",
+				tc[0], tc[1], tc[2], got, want)
+		}
+	}
+
+	for _, tc := range [][3]int{
+		{-1, 0, 0},
+		{5, -1, 2},
+		{5, 6, 2},
+		{5, 2, -1},
+		{5, 2, 6},
+	} {
+		checkPanics(t, "Hypergeometric", func() { Hypergeometric(tc[0], tc[1], tc[2]) })
+	}
+}
+
+func TestGamma(t *testing.T) {
+	for _, tc := range []struct {
+		shape, scale float64
+	}{
+		{0.1, 1},
+		{0.5, 2},
+		{1, 1},
+		{2.5, 0.5},
+		{10, 3},
+		{1000, 0.01},
+	} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := Gamma(tc.shape, tc.scale)
+			if x < 0 {
+				t.Fatalf("Gamma(%g, %g): negative: %g", tc.shape, tc.scale, x)
+			}
+			samples[i] = x
+		}
+		mean := tc.shape * tc.scale
+		checkMoments(t, samples, mean, mean*tc.scale)
+	}
+
+	checkPanics(t, "Gamma(0, 1)", func() { Gamma(0, 1) })
+	checkPanics(t, "Gamma(1, 0)", func() { Gamma(1, 0) })
+	checkPanics(t, "Gamma(-1, 1)", func() { Gamma(-1, 1) })
+	checkPanics(t, "Gamma(NaN, 1)", func() { Gamma(math.NaN(), 1) })
+}
+
+func BenchmarkGamma(b *testing.B) {
+	for n := b.N; n > 0; n-- {
+		Gamma(2.5, 1)
+	}
+}
+
+func TestBeta(t *testing.T) {
+	for _, tc := range []struct {
+		alpha, beta float64
+	}{
+		{0.5, 0.5},
+		{1, 1},
+		{2, 5},
+		{0.1, 3},
+		{50, 0.7},
+		{1e-3, 1e-3},
+	} {
+		a, b := tc.alpha, tc.beta
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := Beta(a, b)
+			if !(x > 0 && x < 1) {
+				t.Fatalf("Beta(%g, %g): out of range: %g", a, b, x)
+			}
+			samples[i] = x
+		}
+		mean := a / (a + b)
+		variance := a * b / ((a + b) * (a + b) * (a + b + 1))
+		checkMoments(t, samples, mean, variance)
+	}
+
+	checkPanics(t, "Beta(0, 1)", func() { Beta(0, 1) })
+	checkPanics(t, "Beta(1, 0)", func() { Beta(1, 0) })
+	checkPanics(t, "Beta(NaN, 1)", func() { Beta(math.NaN(), 1) })
+}
+
+func TestBool(t *testing.T) {
+	var counts [2]int
+	for i := 0; i < 100000; i++ {
+		if Bool() {
+			counts[1]++
+		} else {
+			counts[0]++
+		}
+	}
+	checkUniform(t, counts[:])
+}
+
+func TestBernoulli(t *testing.T) {
+	for _, p := range []float64{0, 0.001, 0.3, 0.5, 0.999, 1} {
+		var counts [2]int
+		for i := 0; i < 100000; i++ {
+			if Bernoulli(p) {
+				counts[1]++
+			} else {
+				counts[0]++
+			}
+		}
+		checkChiSquare(t, counts[:], []float64{1 - p, p})
+	}
+	checkPanics(t, "Bernoulli(-0.1)", func() { Bernoulli(-0.1) })
+	checkPanics(t, "Bernoulli(1.1)", func() { Bernoulli(1.1) })
+	checkPanics(t, "Bernoulli(NaN)", func() { Bernoulli(math.NaN()) })
+}
+
+func TestSubsetMask(t *testing.T) {
+	for _, p := range []float64{0, 0.001, 0.3, 0.5, 0.999, 1} {
+		for _, n := range []int{0, 1, 63, 64, 65, 100000} {
+			mask := SubsetMask(n, p)
+			if len(mask) != n {
+				t.Fatalf("SubsetMask(%d, %g): got %d elements", n, p, len(mask))
+			}
+			if n < 100000 {
+				continue
+			}
+			var counts [2]int
+			for _, b := range mask {
+				counts[b2i(b)]++
+			}
+			checkChiSquare(t, counts[:], []float64{1 - p, p})
+		}
+	}
+
+	// Every position is reachable when p is 0.5.
+	or := make([]bool, 130)
+	for i := 0; i < 100; i++ {
+		for j, b := range SubsetMask(len(or), 0.5) {
+			or[j] = or[j] || b
+		}
+	}
+	for j, b := range or {
+		if !b {
+			t.Fatalf("element %d was never true", j)
+		}
+	}
+
+	checkPanics(t, "SubsetMask(-1, 0.5)", func() { SubsetMask(-1, 0.5) })
+	checkPanics(t, "SubsetMask(1, -0.1)", func() { SubsetMask(1, -0.1) })
+	checkPanics(t, "SubsetMask(1, 1.1)", func() { SubsetMask(1, 1.1) })
+	checkPanics(t, "SubsetMask(1, NaN)", func() { SubsetMask(1, math.NaN()) })
+}
+
+func TestDirichlet(t *testing.T) {
+	for _, alpha := range [][]float64{
+		{1},
+		{1, 1},
+		{0.5, 2, 7.5},
+		{1e-3, 1e-3, 1e-3, 1e-3},
+		{100, 200},
+	} {
+		var asum float64
+		for _, a := range alpha {
+			asum += a
+		}
+		samples := make([][]float64, len(alpha))
+		for i := range samples {
+			samples[i] = make([]float64, 50000)
+		}
+		for j := 0; j < len(samples[0]); j++ {
+			x := Dirichlet(alpha)
+			if len(x) != len(alpha) {
+				t.Fatalf("%v: got %d elements", alpha, len(x))
+			}
+			var sum float64
+			for i, v := range x {
+				if !(v >= 0 && v <= 1) {
+					t.Fatalf("%v: element out of range: %v", alpha, x)
+				}
+				sum += v
+				samples[i][j] = v
+			}
+			if math.Abs(sum-1) > 1e-12 {
+				t.Fatalf("%v: elements sum to %g", alpha, sum)
+			}
+		}
+		for i, a := range alpha {
+			// Each element has a Beta(a, asum-a) marginal.
+			mean := a / asum
+			variance := a * (asum - a) / (asum * asum * (asum + 1))
+			checkMoments(t, samples[i], mean, variance)
+		}
+	}
+
+	checkPanics(t, "Dirichlet(nil)", func() { Dirichlet(nil) })
+	checkPanics(t, "Dirichlet(0)", func() { Dirichlet([]float64{1, 0}) })
+	checkPanics(t, "Dirichlet(-1)", func() { Dirichlet([]float64{-1}) })
+	checkPanics(t, "Dirichlet(NaN)", func() { Dirichlet([]float64{math.NaN()}) })
+	checkPanics(t, "Dirichlet(Inf)", func() { Dirichlet([]float64{math.Inf(1)}) })
+}
+
+func TestTriangular(t *testing.T) {
+	for _, tc := range []struct {
+		min, mode, max float64
+	}{
+		{0, 0.5, 1},
+		{0, 0, 1},
+		{0, 1, 1},
+		{-10, 3, 4},
+		{100, 150, 1000},
+		{2, 2, 2},
+	} {
+		a, c, b := tc.min, tc.mode, tc.max
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := Triangular(a, c, b)
+			if x < a || x > b {
+				t.Fatalf("Triangular(%g, %g, %g): out of range: %g", a, c, b, x)
+			}
+			samples[i] = x
+		}
+		mean := (a + b + c) / 3
+		variance := (a*a + b*b + c*c - a*b - a*c - b*c) / 18
+		checkMoments(t, samples, mean, variance)
+	}
+
+	checkPanics(t, "mode < min", func() { Triangular(0, -1, 1) })
+	checkPanics(t, "mode > max", func() { Triangular(0, 2, 1) })
+	checkPanics(t, "min > max", func() { Triangular(1, 1, 0) })
+	checkPanics(t, "NaN", func() { Triangular(0, math.NaN(), 1) })
+	checkPanics(t, "Inf", func() { Triangular(0, 1, math.Inf(1)) })
+}
+
+func TestMultinomial(t *testing.T) {
+	for _, tc := range []struct {
+		n int
+		p []float64
+	}{
+		{0, []float64{1}},
+		{10, []float64{1}},
+		{100, []float64{0.5, 0.5}},
+		{1000, []float64{0.1, 0.2, 0.3, 0.4}},
+		{50, []float64{0.25, 0, 0.75}},
+		{1e6, []float64{0.01, 0.09, 0.9}},
+		{20, []float64{0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1, 0.1}},
+	} {
+		totals := make([]int, len(tc.p))
+		const runs = 5000
+		for i := 0; i < runs; i++ {
+			counts := Multinomial(tc.n, tc.p)
+			if len(counts) != len(tc.p) {
+				t.Fatalf("%d, %v: got %d counts", tc.n, tc.p, len(counts))
+			}
+			var sum int
+			for j, c := range counts {
+				if c < 0 || (tc.p[j] == 0 && c != 0) {
+					t.Fatalf("%d, %v: invalid counts %v", tc.n, tc.p, counts)
+				}
+				sum += c
+				totals[j] += c
+			}
+			if sum != tc.n {
+				t.Fatalf("%d, %v: counts sum to %d", tc.n, tc.p, sum)
+			}
+		}
+		if tc.n > 0 {
+			checkChiSquare(t, totals, tc.p)
+		}
+	}
+
+	checkPanics(t, "n < 0", func() { Multinomial(-1, []float64{1}) })
+	checkPanics(t, "empty", func() { Multinomial(1, nil) })
+	checkPanics(t, "negative", func() { Multinomial(1, []float64{1.5, -0.5}) })
+	checkPanics(t, "sum < 1", func() { Multinomial(1, []float64{0.5, 0.4}) })
+	checkPanics(t, "NaN", func() { Multinomial(1, []float64{math.NaN()}) })
+}
+
+// median returns the median of samples, sorting them in the
+// process.
+func median(samples []float64) float64 {
+	sort.Float64s(samples)
+	n := len(samples)
+	if n%2 == 1 {
+		return samples[n/2]
+	}
+	return (samples[n/2-1] + samples[n/2]) / 2
+}
+
+func TestCauchy(t *testing.T) {
+	for _, tc := range []struct {
+		x0, gamma float64
+	}{
+		{0, 1},
+		{-5, 0.1},
+		{1000, 50},
+	} {
+		samples := make([]float64, 100001)
+		for i := range samples {
+			x := Cauchy(tc.x0, tc.gamma)
+			if math.IsInf(x, 0) || math.IsNaN(x) {
+				t.Fatalf("Cauchy(%g, %g): got %g", tc.x0, tc.gamma, x)
+			}
+			samples[i] = x
+		}
+		// The standard error of the sample median is
+		// pi*gamma/(2*sqrt(n)).
+		m := median(samples)
+		se := math.Pi * tc.gamma / (2 * math.Sqrt(float64(len(samples))))
+		if math.Abs(m-tc.x0) > 6*se {
+			t.Errorf("Cauchy(%g, %g): median %g", tc.x0, tc.gamma, m)
+		}
+		// Half of the samples are within gamma of x0.
+		q1, q3 := samples[len(samples)/4], samples[3*len(samples)/4]
+		if math.Abs(q1-(tc.x0-tc.gamma)) > 10*se || math.Abs(q3-(tc.x0+tc.gamma)) > 10*se {
+			t.Errorf("Cauchy(%g, %g): quartiles %g, %g", tc.x0, tc.gamma, q1, q3)
+		}
+	}
+
+	// U near 0 or 1 must not produce an infinity.
+	for _, x := range []uint64{0, 1 << 11, math.MaxUint64} {
+		r := NewWithSource(&seqSource{vals: []uint64{x, 1 << 40}})
+		if c := r.Cauchy(0, 1); math.IsInf(c, 0) || math.IsNaN(c) {
+			t.Fatalf("%#x: got %g", x, c)
+		}
+	}
+
+	checkPanics(t, "Cauchy(0, 0)", func() { Cauchy(0, 0) })
+	checkPanics(t, "Cauchy(0, -1)", func() { Cauchy(0, -1) })
+	checkPanics(t, "Cauchy(0, NaN)", func() { Cauchy(0, math.NaN()) })
+}
+
+func TestLogNormal(t *testing.T) {
+	for _, tc := range []struct {
+		mu, sigma float64
+	}{
+		{0, 1},
+		{2, 0.25},
+		{-3, 2},
+		{5, 0},
+	} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := LogNormal(tc.mu, tc.sigma)
+			if !(x > 0) {
+				t.Fatalf("LogNormal(%g, %g): got %g", tc.mu, tc.sigma, x)
+			}
+			samples[i] = math.Log(x)
+		}
+		checkMoments(t, samples, tc.mu, tc.sigma*tc.sigma)
+	}
+	if x := LogNormal(-1e4, 1); !(x > 0) {
+		t.Fatalf("LogNormal(-1e4, 1): got %g", x)
+	}
+	checkPanics(t, "LogNormal(0, -1)", func() { LogNormal(0, -1) })
+	checkPanics(t, "LogNormal(0, NaN)", func() { LogNormal(0, math.NaN()) })
+}
+
+func TestPareto(t *testing.T) {
+	for _, tc := range []struct {
+		xm, alpha float64
+	}{
+		{1, 1},
+		{2, 3},
+		{0.5, 0.5},
+		{100, 10},
+	} {
+		// Bucket by the survival function P(X > x) =
+		// (xm/x)^alpha. Fixed bounds leave some buckets nearly
+		// empty for large alpha, so choose the bounds with
+		// survival {0.5, 0.25, 0.1, 0.01} instead.
+		survival := []float64{0.5, 0.25, 0.1, 0.01}
+		bounds := make([]float64, len(survival))
+		probs := make([]float64, len(survival)+1)
+		prev := 1.0
+		for i, s := range survival {
+			bounds[i] = math.Pow(1/s, 1/tc.alpha)
+			probs[i] = prev - s
+			prev = s
+		}
+		probs[len(survival)] = prev
+
+		counts := make([]int, len(probs))
+		for i := 0; i < 100000; i++ {
+			x := Pareto(tc.xm, tc.alpha)
+			if x < tc.xm {
+				t.Fatalf("Pareto(%g, %g): %g < xm", tc.xm, tc.alpha, x)
+			}
+			j := sort.SearchFloat64s(bounds, x/tc.xm)
+			counts[j]++
+		}
+		checkChiSquare(t, counts, probs)
+	}
+	checkPanics(t, "Pareto(0, 1)", func() { Pareto(0, 1) })
+	checkPanics(t, "Pareto(1, 0)", func() { Pareto(1, 0) })
+	checkPanics(t, "Pareto(NaN, 1)", func() { Pareto(math.NaN(), 1) })
+}
+
+func TestWeibull(t *testing.T) {
+	for _, tc := range []struct {
+		lambda, k float64
+	}{
+		{1, 0.5},
+		{1, 1},
+		{2, 1.5},
+		{0.5, 5},
+	} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := Weibull(tc.lambda, tc.k)
+			if !(x >= 0) || math.IsInf(x, 0) {
+				t.Fatalf("Weibull(%g, %g): got %g", tc.lambda, tc.k, x)
+			}
+			samples[i] = x
+		}
+		g1 := math.Gamma(1 + 1/tc.k)
+		g2 := math.Gamma(1 + 2/tc.k)
+		mean := tc.lambda * g1
+		checkMoments(t, samples, mean, tc.lambda*tc.lambda*(g2-g1*g1))
+	}
+	checkPanics(t, "Weibull(0, 1)", func() { Weibull(0, 1) })
+	checkPanics(t, "Weibull(1, 0)", func() { Weibull(1, 0) })
+	checkPanics(t, "Weibull(1, NaN)", func() { Weibull(1, math.NaN()) })
+}
+
+func TestLaplace(t *testing.T) {
+	for _, tc := range []struct {
+		mu, b float64
+	}{
+		{0, 1},
+		{5, 0.5},
+		{-2, 10},
+	} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := Laplace(tc.mu, tc.b)
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				t.Fatalf("Laplace(%g, %g): got %g", tc.mu, tc.b, x)
+			}
+			samples[i] = x
+		}
+		checkMoments(t, samples, tc.mu, 2*tc.b*tc.b)
+	}
+
+	// The extremes of U must not reach log(0).
+	for _, v := range []uint64{1, 1<<53 - 1} {
+		r := NewWithSource(fixedSource(v))
+		if x := r.Laplace(0, 1); math.IsNaN(x) || math.IsInf(x, 0) {
+			t.Fatalf("Laplace with Uint64 = %#x: got %g", v, x)
+		}
+	}
+
+	checkPanics(t, "Laplace(0, 0)", func() { Laplace(0, 0) })
+	checkPanics(t, "Laplace(0, -1)", func() { Laplace(0, -1) })
+	checkPanics(t, "Laplace(0, NaN)", func() { Laplace(0, math.NaN()) })
+}
+
+func TestChiSquared(t *testing.T) {
+	for _, k := range []float64{0.5, 1, 2, 5, 30} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := ChiSquared(k)
+			if x < 0 {
+				t.Fatalf("ChiSquared(%g): negative: %g", k, x)
+			}
+			samples[i] = x
+		}
+		checkMoments(t, samples, k, 2*k)
+	}
+	checkPanics(t, "ChiSquared(0)", func() { ChiSquared(0) })
+	checkPanics(t, "ChiSquared(-1)", func() { ChiSquared(-1) })
+	checkPanics(t, "ChiSquared(NaN)", func() { ChiSquared(math.NaN()) })
+}
+
+func TestStudentsT(t *testing.T) {
+	for _, nu := range []float64{5, 10, 50} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			samples[i] = StudentsT(nu)
+		}
+		checkMoments(t, samples, 0, nu/(nu-2))
+	}
+
+	// With one degree of freedom, T is standard Cauchy: half
+	// of the mass is in [-1, 1].
+	var inside [2]int
+	for i := 0; i < 100000; i++ {
+		x := StudentsT(1)
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			t.Fatalf("StudentsT(1): got %g", x)
+		}
+		inside[b2i(math.Abs(x) <= 1)]++
+	}
+	checkUniform(t, inside[:])
+
+	for i := 0; i < 1000; i++ {
+		if x := StudentsT(0.01); math.IsNaN(x) || math.IsInf(x, 0) {
+			t.Fatalf("StudentsT(0.01): got %g", x)
+		}
+	}
+
+	checkPanics(t, "StudentsT(0)", func() { StudentsT(0) })
+	checkPanics(t, "StudentsT(NaN)", func() { StudentsT(math.NaN()) })
+}
+
+func TestRayleigh(t *testing.T) {
+	for _, sigma := range []float64{0.5, 1, 3} {
+		samples := make([]float64, 100000)
+		for i := range samples {
+			x := Rayleigh(sigma)
+			if !(x >= 0) || math.IsInf(x, 0) {
+				t.Fatalf("Rayleigh(%g): got %g", sigma, x)
+			}
+			samples[i] = x
+		}
+		mean := sigma * math.Sqrt(math.Pi/2)
+		checkMoments(t, samples, mean, (4-math.Pi)/2*sigma*sigma)
+	}
+	for _, v := range []uint64{0, math.MaxUint64} {
+		r := NewWithSource(fixedSource(v))
+		if x := r.Rayleigh(1); !(x >= 0) || math.IsInf(x, 0) {
+			t.Fatalf("Rayleigh with Uint64 = %#x: got %g", v, x)
+		}
+	}
+	checkPanics(t, "Rayleigh(0)", func() { Rayleigh(0) })
+	checkPanics(t, "Rayleigh(NaN)", func() { Rayleigh(math.NaN()) })
+}
+
+func TestLogUniform(t *testing.T) {
+	for _, tc := range []struct {
+		min, max float64
+	}{
+		{1, 10},
+		{1e-5, 1},
+		{0.5, 0.75},
+		{1e-300, 1e300},
+	} {
+		lo, hi := math.Log(tc.min), math.Log(tc.max)
+		counts := make([]int, 10)
+		for i := 0; i < 50000; i++ {
+			x := LogUniform(tc.min, tc.max)
+			if !(x >= tc.min && x < tc.max) {
+				t.Fatalf("LogUniform(%g, %g): out of range: %g", tc.min, tc.max, x)
+			}
+			j := int((math.Log(x) - lo) / (hi - lo) * 10)
+			if j == 10 {
+				j = 9
+			}
+			counts[j]++
+		}
+		checkUniform(t, counts)
+	}
+
+	// The largest Float64 would round to max.
+	r := NewWithSource(fixedSource(1<<53 - 1))
+	if x := r.LogUniform(1, math.Nextafter(1, 2)); x != 1 {
+		t.Fatalf("expected 1, got %g", x)
+	}
+
+	for _, tc := range [][2]float64{
+		{0, 1},
+		{-1, 1},
+		{2, 1},
+		{1, 1},
+		{math.NaN(), 1},
+		{1, math.Inf(1)},
+	} {
+		checkPanics(t, "LogUniform", func() { LogUniform(tc[0], tc[1]) })
+	}
+}
@@ -0,0 +1,76 @@
+package saferand
+
+import (
+	"math"
+	"sort"
+)
+
+// Empirical samples from the empirical distribution of a fixed
+// set of observations.
+//
+// An Empirical is safe for concurrent use by multiple
+// goroutines.
+type Empirical struct {
+	// values are the observations in ascending order.
+	values []float64
+}
+
+// NewEmpirical creates an Empirical from the observations in
+// values.
+//
+// values is copied and sorted once, so it may be modified after
+// NewEmpirical returns.
+//
+// It panics if values is empty or contains a NaN.
+func NewEmpirical(values []float64) *Empirical {
+	if len(values) == 0 {
+		panic("invalid argument to NewEmpirical")
+	}
+	for _, v := range values {
+		if math.IsNaN(v) {
+			panic("invalid argument to NewEmpirical")
+		}
+	}
+	e := &Empirical{
+		values: make([]float64, len(values)),
+	}
+	copy(e.values, values)
+	sort.Float64s(e.values)
+	return e
+}
+
+// Len returns the number of observations.
+func (e *Empirical) Len() int {
+	return len(e.values)
+}
+
+// Sample returns one of the observations, chosen uniformly.
+//
+// This is resampling with replacement, as used by the
+// bootstrap. The result always equals one of the observations.
+func (e *Empirical) Sample(r *Rand) float64 {
+	return e.values[r.Uint64n(uint64(len(e.values)))]
+}
+
+// Interpolate returns a value from the continuous distribution
+// whose CDF linearly interpolates between the sorted
+// observations.
+//
+// The result is in [min, max] of the observations and may fall
+// between them. If there is only one observation, Interpolate
+// returns it.
+func (e *Empirical) Interpolate(r *Rand) float64 {
+	n := len(e.values)
+	if n == 1 {
+		return e.values[0]
+	}
+	u := r.Float64() * float64(n-1)
+	i := int(u)
+	if i >= n-1 {
+		// Guard against rounding up to n-1.
+		i = n - 2
+	}
+	lo, hi := e.values[i], e.values[i+1]
+	x := lo + (u-float64(i))*(hi-lo)
+	return math.Max(lo, math.Min(x, hi))
+}
@@ -0,0 +1,98 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEmpiricalSample(t *testing.T) {
+	values := []float64{5, -1, 3, 3, 10, 0.5, 7}
+	e := NewEmpirical(values)
+	if e.Len() != len(values) {
+		t.Fatalf("Len: expected %d, got %d", len(values), e.Len())
+	}
+	// Modifying the input does not affect e.
+	values[0] = 1e9
+
+	var sum, sumsq float64
+	for _, v := range e.values {
+		sum += v
+		sumsq += v * v
+	}
+	n := float64(e.Len())
+	mean := sum / n
+	variance := sumsq/n - mean*mean
+
+	r := New()
+	counts := make(map[float64]int)
+	samples := make([]float64, 100000)
+	for i := range samples {
+		x := e.Sample(r)
+		counts[x]++
+		samples[i] = x
+	}
+	if _, ok := counts[1e9]; ok {
+		t.Fatal("Sample returned a value modified after NewEmpirical")
+	}
+	checkChiSquare(t,
+		[]int{counts[-1], counts[0.5], counts[3], counts[5], counts[7], counts[10]},
+		[]float64{1 / n, 1 / n, 2 / n, 1 / n, 1 / n, 1 / n})
+	checkMoments(t, samples, mean, variance)
+}
+
+func TestEmpiricalInterpolate(t *testing.T) {
+	values := []float64{4, 0, 1, 2, 10}
+	e := NewEmpirical(values)
+
+	// The interpolated distribution is uniform on each of the
+	// n-1 segments between sorted observations, so its mean is
+	// the average of the segment midpoints.
+	sorted := e.values
+	segs := float64(len(sorted) - 1)
+	var mean, m2 float64
+	for i := 0; i+1 < len(sorted); i++ {
+		a, b := sorted[i], sorted[i+1]
+		mean += (a + b) / 2 / segs
+		m2 += (a*a + a*b + b*b) / 3 / segs
+	}
+	variance := m2 - mean*mean
+
+	r := New()
+	samples := make([]float64, 100000)
+	var between bool
+	for i := range samples {
+		x := e.Interpolate(r)
+		if x < 0 || x > 10 {
+			t.Fatalf("Interpolate: out of range: %g", x)
+		}
+		if x != math.Trunc(x) {
+			between = true
+		}
+		samples[i] = x
+	}
+	if !between {
+		t.Fatal("Interpolate never returned a value between observations")
+	}
+	checkMoments(t, samples, mean, variance)
+
+	// The largest Float64 must not index past the end.
+	hi := NewWithSource(fixedSource(1<<53 - 1))
+	if x := e.Interpolate(hi); x > 10 || x < 4 {
+		t.Fatalf("Interpolate with largest Float64: got %g", x)
+	}
+
+	one := NewEmpirical([]float64{42})
+	if x := one.Interpolate(r); x != 42 {
+		t.Fatalf("Interpolate with one value: got %g", x)
+	}
+	if x := one.Sample(r); x != 42 {
+		t.Fatalf("Sample with one value: got %g", x)
+	}
+}
+
+func TestEmpiricalInvalid(t *testing.T) {
+	checkPanics(t, "NewEmpirical(nil)", func() { NewEmpirical(nil) })
+	checkPanics(t, "NewEmpirical(NaN)", func() {
+		NewEmpirical([]float64{1, math.NaN()})
+	})
+}
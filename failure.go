@@ -0,0 +1,120 @@
+package saferand
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// FailurePolicy determines how ExpSource's Int63 and Uint64
+// methods respond when the underlying reader fails.
+//
+// The policy applies to every ExpSource, including the one
+// used by the package-level functions and the Rands returned by
+// New. It does not affect Read or the Try functions and
+// methods, which always return the error.
+type FailurePolicy struct {
+	kind failureKind
+	fn   func(error)
+}
+
+type failureKind uint8
+
+const (
+	failPanic failureKind = iota
+	failError
+	failFunc
+)
+
+var (
+	// PolicyPanic panics with the error. It is the default.
+	PolicyPanic = FailurePolicy{kind: failPanic}
+	// PolicyError records the error and returns zero. The
+	// next call to TryInt63 or TryUint64, either the
+	// package-level functions or the ExpSource methods,
+	// returns the recorded error.
+	//
+	// If another read fails before the recorded error has been
+	// returned, PolicyError panics with the new error. Many
+	// functions reject a zero draw and try again, so returning
+	// zero a second time could spin forever instead of
+	// returning to the caller.
+	//
+	// Values returned after a failure are NOT random. Only use
+	// PolicyError if callers periodically check for failures
+	// with the Try API.
+	PolicyError = FailurePolicy{kind: failError}
+)
+
+// PolicyFunc returns a FailurePolicy that calls fn with the
+// error, then retries the read.
+//
+// fn can log the error, sleep, or shed load before returning.
+// If fn panics, the panic propagates to the caller. fn may be
+// called concurrently by multiple goroutines.
+//
+// It panics if fn is nil.
+func PolicyFunc(fn func(err error)) FailurePolicy {
+	if fn == nil {
+		panic("invalid argument to PolicyFunc")
+	}
+	return FailurePolicy{kind: failFunc, fn: fn}
+}
+
+// policy holds the FailurePolicy installed by
+// SetFailurePolicy.
+var policy atomic.Value
+
+// SetFailurePolicy sets how ExpSource's Int63 and Uint64
+// methods respond when the underlying reader fails.
+//
+// It is safe to call concurrently with the rest of the
+// package.
+func SetFailurePolicy(p FailurePolicy) {
+	policy.Store(p)
+}
+
+var (
+	// failed is non-zero when failErr is set.
+	failed  uint32
+	failMu  sync.Mutex
+	failErr error
+)
+
+// fail handles err according to the current FailurePolicy.
+//
+// It reports whether the caller should retry.
+func fail(err error) (retry bool) {
+	p, _ := policy.Load().(FailurePolicy)
+	switch p.kind {
+	case failError:
+		failMu.Lock()
+		defer failMu.Unlock()
+		if failErr != nil {
+			// Fail closed: the caller ignored the first
+			// failure and is still reading.
+			panic(err)
+		}
+		failErr = err
+		atomic.StoreUint32(&failed, 1)
+		return false
+	case failFunc:
+		p.fn(err)
+		return true
+	default:
+		panic(err)
+	}
+}
+
+// pendingFailure returns and clears the error recorded under
+// PolicyError, if any.
+func pendingFailure() error {
+	if atomic.LoadUint32(&failed) == 0 {
+		return nil
+	}
+	failMu.Lock()
+	defer failMu.Unlock()
+	err := failErr
+	failErr = nil
+	atomic.StoreUint32(&failed, 0)
+	return err
+}
@@ -0,0 +1,163 @@
+package saferand
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+var errEntropy = errors.New("entropy unavailable")
+
+// flakyReader fails the first n reads, then reads from r.
+type flakyReader struct {
+	n int
+	r io.Reader
+}
+
+func (f *flakyReader) Read(p []byte) (int, error) {
+	if f.n > 0 {
+		f.n--
+		return 0, errEntropy
+	}
+	return f.r.Read(p)
+}
+
+func TestFailurePolicyPanic(t *testing.T) {
+	SetFailurePolicy(PolicyPanic)
+	src := NewSourceFromReader(iotest.ErrReader(errEntropy))
+
+	defer func() {
+		if err := recover(); err != errEntropy {
+			t.Fatalf("expected panic with %v, got %v", errEntropy, err)
+		}
+	}()
+	src.Uint64()
+}
+
+func TestFailurePolicyError(t *testing.T) {
+	SetFailurePolicy(PolicyError)
+	defer SetFailurePolicy(PolicyPanic)
+
+	src := NewSourceFromReader(iotest.ErrReader(errEntropy)).(ExpSource)
+	if x := src.Uint64(); x != 0 {
+		t.Fatalf("Uint64: expected 0, got %#x", x)
+	}
+
+	// The recorded error is returned once by the Try API.
+	if _, err := TryUint64(); err != errEntropy {
+		t.Fatalf("TryUint64: expected %v, got %v", errEntropy, err)
+	}
+	if _, err := TryUint64(); err != nil {
+		t.Fatalf("TryUint64: unexpected error: %v", err)
+	}
+
+	if x := src.Int63(); x != 0 {
+		t.Fatalf("Int63: expected 0, got %#x", x)
+	}
+	if _, err := TryInt63(); err != errEntropy {
+		t.Fatalf("TryInt63: expected %v, got %v", errEntropy, err)
+	}
+
+	// A second failure before the first is returned panics,
+	// and the first error stays recorded.
+	NewSourceFromReader(iotest.ErrReader(io.ErrUnexpectedEOF)).Uint64()
+	func() {
+		defer func() {
+			if err := recover(); err != errEntropy {
+				t.Fatalf("expected panic with %v, got %v", errEntropy, err)
+			}
+		}()
+		src.Uint64()
+	}()
+	if _, err := src.TryInt63(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("TryInt63: expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	// The Try API still reports its own failures.
+	if _, err := src.TryUint64(); err != errEntropy {
+		t.Fatalf("TryUint64: expected %v, got %v", errEntropy, err)
+	}
+	if pendingFailure() != nil {
+		t.Fatal("Try error should not be recorded")
+	}
+}
+
+// TestFailurePolicyErrorRejection checks that functions that
+// reject a zero draw terminate under PolicyError instead of
+// retrying forever.
+func TestFailurePolicyErrorRejection(t *testing.T) {
+	SetFailurePolicy(PolicyError)
+	defer SetFailurePolicy(PolicyPanic)
+	defer SetReader(iotest.ErrReader(errEntropy))()
+
+	for _, tc := range []struct {
+		name string
+		fn   func()
+	}{
+		{"Uint32n", func() { Uint32n(3) }},
+		{"Int63nFast", func() { Int63nFast(3) }},
+		{"Geometric", func() { Geometric(0.5) }},
+	} {
+		done := make(chan interface{})
+		go func() {
+			defer func() { done <- recover() }()
+			tc.fn()
+		}()
+		select {
+		case err := <-done:
+			if err != errEntropy {
+				t.Errorf("%s: expected panic with %v, got %v", tc.name, errEntropy, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: did not return", tc.name)
+		}
+		if err := pendingFailure(); err != errEntropy {
+			t.Errorf("%s: expected recorded %v, got %v", tc.name, errEntropy, err)
+		}
+	}
+}
+
+func TestFailurePolicyFunc(t *testing.T) {
+	var errs []error
+	SetFailurePolicy(PolicyFunc(func(err error) {
+		errs = append(errs, err)
+	}))
+	defer SetFailurePolicy(PolicyPanic)
+
+	buf := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	src := NewSourceFromReader(&flakyReader{n: 3, r: bytes.NewReader(buf)})
+	if got, want := src.Uint64(), uint64(0x0807060504030201); got != want {
+		t.Fatalf("Uint64: expected %#x, got %#x", want, got)
+	}
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(errs))
+	}
+	for i, err := range errs {
+		if err != errEntropy {
+			t.Fatalf("#%d: expected %v, got %v", i, errEntropy, err)
+		}
+	}
+
+	// The callback can abort by panicking.
+	errShed := errors.New("shed load")
+	SetFailurePolicy(PolicyFunc(func(err error) { panic(errShed) }))
+	func() {
+		defer func() {
+			if err := recover(); err != errShed {
+				t.Fatalf("expected panic with %v, got %v", errShed, err)
+			}
+		}()
+		NewWithSource(NewSourceFromReader(iotest.ErrReader(errEntropy))).Intn(10)
+	}()
+
+	// The Try API ignores the policy.
+	src = NewSourceFromReader(iotest.ErrReader(errEntropy))
+	if _, err := src.(TrySource).TryUint64(); err != errEntropy {
+		t.Fatalf("TryUint64: expected %v, got %v", errEntropy, err)
+	}
+
+	checkPanics(t, "PolicyFunc(nil)", func() { PolicyFunc(nil) })
+}
@@ -0,0 +1,45 @@
+package saferand
+
+// Float64Full returns a uniform random number in [0.0, 1.0)
+// with the full 53 bits of precision.
+func Float64Full() float64 { return defaultRand.Float64Full() }
+
+// Float64Full returns a uniform random number in [0.0, 1.0)
+// with the full 53 bits of precision.
+//
+// The result is built directly from the high 53 bits of a single
+// Uint64, so each of the 2^53 possible outputs k/2^53 is equally
+// likely and 1.0 is never returned. The smallest positive
+// result is 2^-53.
+//
+// Float64 (inherited from golang.org/x/exp/rand) also produces
+// multiples of 2^-53, but uses the low 53 bits and a retry loop
+// to guard against rounding up to 1.0. Float64Full never needs
+// to retry.
+func (r *Rand) Float64Full() float64 {
+	return float64(r.Uint64()>>11) * (1.0 / (1 << 53))
+}
+
+// Float64Pos returns a uniform random number in (0.0, 1.0].
+func Float64Pos() float64 { return defaultRand.Float64Pos() }
+
+// Float64Pos returns a uniform random number in (0.0, 1.0].
+//
+// Unlike Float64 and Float64Full, which return values in
+// [0.0, 1.0), it never returns 0 and may return 1.0. This makes
+// it safe to pass directly to math.Log.
+//
+// Each of the 2^53 possible outputs k/2^53, for k in
+// [1, 2^53], is equally likely.
+func (r *Rand) Float64Pos() float64 {
+	return float64(r.Uint64()>>11+1) * (1.0 / (1 << 53))
+}
+
+// float64Open returns a uniform random number in (0.0, 1.0).
+func (r *Rand) float64Open() float64 {
+	for {
+		if u := r.Float64(); u != 0 {
+			return u
+		}
+	}
+}
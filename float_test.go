@@ -0,0 +1,117 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+)
+
+// fixedSource is a Source that always returns the same value.
+type fixedSource uint64
+
+func (fixedSource) Seed(_ uint64)    {}
+func (s fixedSource) Int63() int64   { return int64(s &^ (1 << 63)) }
+func (s fixedSource) Uint64() uint64 { return uint64(s) }
+
+func TestFloat64FullBounds(t *testing.T) {
+	for _, tc := range []struct {
+		x    uint64
+		want float64
+	}{
+		{0, 0},
+		{1<<11 - 1, 0},
+		{1 << 11, 0x1p-53},
+		{1 << 63, 0.5},
+		{math.MaxUint64, 1 - 0x1p-53},
+	} {
+		r := NewWithSource(fixedSource(tc.x))
+		if got := r.Float64Full(); got != tc.want {
+			t.Errorf("%#x: got %g, expected %g", tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestFloat64Full(t *testing.T) {
+	var counts [16]int
+	var low uint64
+	for i := 0; i < 100000; i++ {
+		f := Float64Full()
+		if f < 0 || f >= 1 {
+			t.Fatalf("#%d: out of range: %g", i, f)
+		}
+		counts[int(f*16)]++
+		low |= uint64(f*(1<<53)) & 0xff
+	}
+	if low != 0xff {
+		t.Fatalf("low bits are never set: %#x", low)
+	}
+	checkUniform(t, counts[:])
+}
+
+func TestFloat64PosBounds(t *testing.T) {
+	for _, tc := range []struct {
+		x    uint64
+		want float64
+	}{
+		{0, 0x1p-53},
+		{1<<11 - 1, 0x1p-53},
+		{1 << 11, 0x1p-52},
+		{1 << 63, 0.5 + 0x1p-53},
+		{math.MaxUint64, 1},
+	} {
+		r := NewWithSource(fixedSource(tc.x))
+		if got := r.Float64Pos(); got != tc.want {
+			t.Errorf("%#x: got %g, expected %g", tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestFloat64Pos(t *testing.T) {
+	var counts [16]int
+	min := 1.0
+	for i := 0; i < 100000; i++ {
+		f := Float64Pos()
+		if !(f > 0 && f <= 1) {
+			t.Fatalf("#%d: out of range: %g", i, f)
+		}
+		if f < min {
+			min = f
+		}
+		counts[int(math.Ceil(f*16))-1]++
+	}
+	if !(min > 0) {
+		t.Fatalf("minimum is not positive: %g", min)
+	}
+	checkUniform(t, counts[:])
+}
+
+// seqSource is a Source that returns vals in order, then
+// repeats the last value.
+type seqSource struct {
+	vals []uint64
+}
+
+func (*seqSource) Seed(_ uint64) {}
+
+func (s *seqSource) Int63() int64 {
+	return int64(s.Uint64() &^ (1 << 63))
+}
+
+func (s *seqSource) Uint64() uint64 {
+	x := s.vals[0]
+	if len(s.vals) > 1 {
+		s.vals = s.vals[1:]
+	}
+	return x
+}
+
+func TestFloat64Open(t *testing.T) {
+	r := NewWithSource(&seqSource{vals: []uint64{0, 0, 1}})
+	if got := r.float64Open(); got != 0x1p-53 {
+		t.Fatalf("expected %g, got %g", 0x1p-53, got)
+	}
+	for i := 0; i < 10000; i++ {
+		if u := defaultRand.float64Open(); u <= 0 || u >= 1 {
+			t.Fatalf("out of range: %g", u)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package saferand
+
+// getpid returns the current process ID.
+//
+// Stateful Sources compare it against the process ID captured
+// when they were last seeded so that a forked child never
+// repeats its parent's output. It is a variable so tests can
+// simulate a fork.
+var getpid = newGetpid()
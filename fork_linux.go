@@ -0,0 +1,66 @@
+package saferand
+
+import (
+	"os"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// newGetpid returns a function that returns the current
+// process ID.
+//
+// getpid(2) is a real system call and costs more than
+// generating a value from a stateful Source, so the PID is
+// cached in a page marked MADV_WIPEONFORK. The kernel zeroes
+// the page in a forked child, which tells the child to look up
+// its new PID.
+//
+// If the kernel does not support MADV_WIPEONFORK (added in
+// Linux 4.14), the returned function calls os.Getpid every
+// time.
+func newGetpid() func() int {
+	page, err := unix.Mmap(-1, 0, os.Getpagesize(),
+		unix.PROT_READ|unix.PROT_WRITE, unix.MAP_PRIVATE|unix.MAP_ANONYMOUS)
+	if err != nil {
+		return os.Getpid
+	}
+	if err := unix.Madvise(page, unix.MADV_WIPEONFORK); err != nil {
+		unix.Munmap(page)
+		return os.Getpid
+	}
+	c := &pidCache{
+		valid:  (*uint32)(unsafe.Pointer(&page[0])),
+		getpid: os.Getpid,
+	}
+	return c.get
+}
+
+// pidCache caches the current process ID.
+type pidCache struct {
+	// pid is the cached process ID.
+	//
+	// Accessed atomically. Keep first so it is 64-bit aligned
+	// on 32-bit platforms.
+	pid int64
+	// valid is non-zero if pid is the current process ID.
+	//
+	// It points into a MADV_WIPEONFORK page.
+	valid *uint32
+	// getpid is os.Getpid, replaceable for testing.
+	getpid func() int
+}
+
+func (c *pidCache) get() int {
+	if atomic.LoadUint32(c.valid) != 0 {
+		return int(atomic.LoadInt64(&c.pid))
+	}
+	// Either this is the first call or the process has
+	// forked. Concurrent callers all store the same PID, so
+	// racing here is harmless.
+	pid := c.getpid()
+	atomic.StoreInt64(&c.pid, int64(pid))
+	atomic.StoreUint32(c.valid, 1)
+	return pid
+}
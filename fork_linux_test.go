@@ -0,0 +1,34 @@
+package saferand
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGetpid(t *testing.T) {
+	if got, want := getpid(), os.Getpid(); got != want {
+		t.Fatalf("expected %d, got %d", want, got)
+	}
+}
+
+// TestPIDCache simulates the kernel wiping the page in a forked
+// child.
+func TestPIDCache(t *testing.T) {
+	var valid uint32
+	pid := 1
+	c := &pidCache{
+		valid:  &valid,
+		getpid: func() int { return pid },
+	}
+	if got := c.get(); got != 1 {
+		t.Fatalf("expected 1, got %d", got)
+	}
+	pid = 2
+	if got := c.get(); got != 1 {
+		t.Fatalf("expected cached PID 1, got %d", got)
+	}
+	valid = 0 // fork
+	if got := c.get(); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package saferand
+
+import (
+	"os"
+)
+
+// newGetpid returns a function that returns the current
+// process ID.
+func newGetpid() func() int {
+	return os.Getpid
+}
@@ -0,0 +1,115 @@
+package saferand
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// withPID replaces getpid for the duration of the test and
+// returns a function that changes the simulated process ID.
+func withPID(t *testing.T) func(pid int) {
+	t.Helper()
+	orig := getpid
+	pid := 1
+	getpid = func() int { return pid }
+	t.Cleanup(func() { getpid = orig })
+	return func(p int) { pid = p }
+}
+
+// forkTest describes a stateful Source for the fork safety
+// tests.
+type forkTest struct {
+	name string
+	src  Source
+	// peek returns the next buffered value.
+	peek func() uint64
+	// pid is the PID captured by the Source.
+	pid         func() int
+	forceReseed func()
+}
+
+func forkTests(t *testing.T) []forkTest {
+	buffered := NewBufferedSource(64)
+	chacha := NewChaChaSource()
+	ctr, err := NewCTRDRBGSource(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []forkTest{
+		{
+			name:        "Buffered",
+			src:         buffered,
+			peek:        func() uint64 { return binary.LittleEndian.Uint64(buffered.buf[buffered.off:]) },
+			pid:         func() int { return buffered.pid },
+			forceReseed: buffered.ForceReseed,
+		},
+		{
+			name:        "ChaCha",
+			src:         chacha,
+			peek:        func() uint64 { return binary.LittleEndian.Uint64(chacha.buf[chacha.off:]) },
+			pid:         func() int { return chacha.pid },
+			forceReseed: chacha.ForceReseed,
+		},
+		{
+			name:        "CTRDRBG",
+			src:         ctr,
+			peek:        func() uint64 { return binary.LittleEndian.Uint64(ctr.buf[ctr.off:]) },
+			pid:         func() int { return ctr.pid },
+			forceReseed: ctr.ForceReseed,
+		},
+	}
+}
+
+// TestForkSafety simulates a fork and checks that the child
+// does not emit the output buffered by the parent.
+func TestForkSafety(t *testing.T) {
+	setPID := withPID(t)
+	for _, tc := range forkTests(t) {
+		tc.src.Uint64()
+
+		// Without a fork, the next value comes from the
+		// buffer.
+		want := tc.peek()
+		if got := tc.src.Uint64(); got != want {
+			t.Fatalf("%s: expected buffered value %#x, got %#x", tc.name, want, got)
+		}
+
+		setPID(2)
+		parent := tc.peek()
+		if got := tc.src.Uint64(); got == parent {
+			t.Fatalf("%s: child repeated the parent's output", tc.name)
+		}
+		if got := tc.pid(); got != 2 {
+			t.Fatalf("%s: expected PID 2, got %d", tc.name, got)
+		}
+		setPID(1)
+	}
+}
+
+func TestForceReseed(t *testing.T) {
+	for _, tc := range forkTests(t) {
+		tc.src.Uint64()
+		old := tc.peek()
+		tc.forceReseed()
+		if got := tc.src.Uint64(); got == old {
+			t.Fatalf("%s: repeated output after ForceReseed", tc.name)
+		}
+	}
+}
+
+// TestCTRDRBGSourceForkRead checks the fork safety of Read,
+// which bypasses the buffer.
+func TestCTRDRBGSourceForkRead(t *testing.T) {
+	setPID := withPID(t)
+	s, err := NewCTRDRBGSource(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setPID(2)
+	if _, err := s.Read(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	if s.pid != 2 {
+		t.Fatalf("expected PID 2, got %d", s.pid)
+	}
+}
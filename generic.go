@@ -0,0 +1,143 @@
+package saferand
+
+import (
+	"math"
+	"sort"
+	"unsafe"
+)
+
+// integer is the set of all integer types.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// N returns a uniform random number in [0, n).
+//
+// It panics if n <= 0.
+func N[T integer](n T) T {
+	if n <= 0 {
+		panic("invalid argument to N")
+	}
+	if unsafe.Sizeof(n) <= 4 {
+		return T(defaultRand.Uint32n(uint32(n)))
+	}
+	return T(defaultRand.Uint64n(uint64(n)))
+}
+
+// Choice returns a uniformly chosen element of s.
+//
+// It panics if s is empty.
+func Choice[T any](s []T) T {
+	if len(s) == 0 {
+		panic("invalid argument to Choice: empty slice")
+	}
+	return s[N(len(s))]
+}
+
+// ChoiceN returns k elements chosen uniformly and independently
+// from s. That is, it samples with replacement.
+//
+// It panics if k < 0, or if s is empty and k > 0.
+func ChoiceN[T any](s []T, k int) []T {
+	if k < 0 || (len(s) == 0 && k > 0) {
+		panic("invalid argument to ChoiceN")
+	}
+	out := make([]T, k)
+	for i := range out {
+		out[i] = s[N(len(s))]
+	}
+	return out
+}
+
+// ChoiceWeighted returns a key from weights chosen with
+// probability proportional to its weight.
+//
+// Keys with zero weight are never chosen. The result does not
+// depend on the map's iteration order: the keys and weights are
+// read in a single pass before sampling.
+//
+// It panics if weights is empty, contains a negative or
+// non-finite weight, or if the total weight is not positive.
+func ChoiceWeighted[K comparable](weights map[K]float64) K {
+	keys := make([]K, 0, len(weights))
+	cum := make([]float64, 0, len(weights))
+	var sum float64
+	for k, w := range weights {
+		if !(w >= 0) || math.IsInf(w, 0) {
+			panic("invalid argument to ChoiceWeighted")
+		}
+		if w == 0 {
+			continue
+		}
+		sum += w
+		keys = append(keys, k)
+		cum = append(cum, sum)
+	}
+	if !(sum > 0) || math.IsInf(sum, 0) {
+		panic("invalid argument to ChoiceWeighted")
+	}
+	x := defaultRand.Float64() * sum
+	i := sort.Search(len(cum), func(i int) bool { return cum[i] > x })
+	if i == len(cum) {
+		// Rounding error.
+		i = len(cum) - 1
+	}
+	return keys[i]
+}
+
+// ChoiceWeightedFunc returns an element of items chosen with
+// probability proportional to weight(item).
+//
+// It calls weight exactly once for each item, in order, and
+// selects in a single pass without storing the weights. Each
+// item with a positive weight replaces the current selection
+// with probability weight/total, where total is the sum of the
+// weights so far (Chao's weighted reservoir sampling). This
+// reads randomness once per item with a positive weight, so for
+// repeated selections from the same items, NewWeighted is
+// faster. Items with zero weight are never chosen.
+//
+// It panics if items is empty, if weight returns a negative or
+// non-finite value, or if the total weight is not positive.
+func ChoiceWeightedFunc[T any](items []T, weight func(T) float64) T {
+	if len(items) == 0 {
+		panic("invalid argument to ChoiceWeightedFunc: empty slice")
+	}
+	var (
+		chosen T
+		sum    float64
+	)
+	for _, item := range items {
+		w := weight(item)
+		if !(w >= 0) || math.IsInf(w, 0) {
+			panic("invalid argument to ChoiceWeightedFunc")
+		}
+		if w == 0 {
+			continue
+		}
+		sum += w
+		if defaultRand.Float64()*sum < w {
+			chosen = item
+		}
+	}
+	if !(sum > 0) || math.IsInf(sum, 0) {
+		panic("invalid argument to ChoiceWeightedFunc")
+	}
+	return chosen
+}
+
+// ShuffledKeys returns the keys of m in a uniformly random
+// order.
+//
+// Go's map iteration order is unspecified, but it is not
+// uniformly random and must not be relied on for security.
+// ShuffledKeys shuffles the keys with ShuffleSlice.
+func ShuffledKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	shuffleSlice(defaultRand, keys)
+	return keys
+}
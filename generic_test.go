@@ -0,0 +1,213 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func testN[T integer](t *testing.T, n T) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		if x := N(n); x < 0 || x >= n {
+			t.Fatalf("N(%d) = %d", n, x)
+		}
+	}
+}
+
+func TestN(t *testing.T) {
+	testN(t, uint8(1))
+	testN(t, uint8(10))
+	testN(t, uint8(math.MaxUint8))
+	testN(t, int8(math.MaxInt8))
+	testN(t, int(1))
+	testN(t, int(1000))
+	testN(t, int(math.MaxInt))
+	testN(t, int32(math.MaxInt32))
+	testN(t, uint32(math.MaxUint32))
+	testN(t, int64(math.MaxInt64))
+	testN(t, uint64(math.MaxUint64))
+	testN(t, time.Duration(time.Hour))
+
+	counts := make([]int, 10)
+	for i := 0; i < 100000; i++ {
+		counts[N(uint8(10))]++
+	}
+	checkUniform(t, counts)
+}
+
+func TestNPanics(t *testing.T) {
+	for _, fn := range []func(){
+		func() { N(0) },
+		func() { N(-1) },
+		func() { N(uint(0)) },
+		func() { N(int8(math.MinInt8)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestChoice(t *testing.T) {
+	s := []string{"a", "b", "c", "d", "e"}
+	index := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4}
+	counts := make([]int, len(s))
+	for i := 0; i < 50000; i++ {
+		counts[index[Choice(s)]]++
+	}
+	checkUniform(t, counts)
+
+	if got := Choice([]int{42}); got != 42 {
+		t.Fatalf("expected 42, got %d", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	Choice([]int{})
+}
+
+func TestChoiceN(t *testing.T) {
+	s := []int{0, 1, 2, 3}
+	counts := make([]int, len(s))
+	for i := 0; i < 10000; i++ {
+		for _, x := range ChoiceN(s, 5) {
+			counts[x]++
+		}
+	}
+	checkUniform(t, counts)
+
+	if got := ChoiceN(s, 0); got == nil || len(got) != 0 {
+		t.Fatalf("expected empty slice, got %v", got)
+	}
+	if got := ChoiceN([]int(nil), 0); len(got) != 0 {
+		t.Fatalf("expected empty slice, got %v", got)
+	}
+
+	for _, fn := range []func(){
+		func() { ChoiceN(s, -1) },
+		func() { ChoiceN([]int{}, 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestChoiceWeighted(t *testing.T) {
+	weights := map[string]float64{
+		"a": 1,
+		"b": 2,
+		"c": 0,
+		"d": 5,
+	}
+	index := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3}
+	counts := make([]int, 4)
+	for i := 0; i < 80000; i++ {
+		counts[index[ChoiceWeighted(weights)]]++
+	}
+	checkChiSquare(t, counts, []float64{1.0 / 8, 2.0 / 8, 0, 5.0 / 8})
+
+	if got := ChoiceWeighted(map[int]float64{7: 0.1}); got != 7 {
+		t.Fatalf("expected 7, got %d", got)
+	}
+
+	for _, m := range []map[int]float64{
+		nil,
+		{},
+		{1: 0},
+		{1: 1, 2: -1},
+		{1: math.NaN()},
+		{1: math.Inf(1)},
+		{1: math.MaxFloat64, 2: math.MaxFloat64},
+	} {
+		checkPanics(t, "ChoiceWeighted", func() { ChoiceWeighted(m) })
+	}
+}
+
+func TestChoiceWeightedFunc(t *testing.T) {
+	type item struct {
+		name   string
+		weight float64
+	}
+	items := []item{{"a", 1}, {"b", 0}, {"c", 2}, {"d", 5}, {"e", 0}}
+	weight := func(it item) float64 { return it.weight }
+	index := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4}
+	counts := make([]int, len(items))
+	for i := 0; i < 80000; i++ {
+		counts[index[ChoiceWeightedFunc(items, weight).name]]++
+	}
+	checkChiSquare(t, counts, []float64{1.0 / 8, 0, 2.0 / 8, 5.0 / 8, 0})
+
+	// The first positive weight is always selected once, even
+	// when it comes last.
+	if got := ChoiceWeightedFunc([]int{1, 2, 3}, func(x int) float64 {
+		return float64(b2i(x == 3))
+	}); got != 3 {
+		t.Fatalf("expected 3, got %d", got)
+	}
+
+	calls := 0
+	ChoiceWeightedFunc([]int{1, 2, 3, 4}, func(x int) float64 {
+		calls++
+		return float64(x)
+	})
+	if calls != 4 {
+		t.Fatalf("weight called %d times, expected 4", calls)
+	}
+
+	for _, ws := range [][]float64{
+		nil,
+		{0, 0},
+		{1, -1},
+		{1, math.NaN()},
+		{math.Inf(1)},
+		{math.MaxFloat64, math.MaxFloat64},
+	} {
+		checkPanics(t, "ChoiceWeightedFunc", func() {
+			ChoiceWeightedFunc(ws, func(w float64) float64 { return w })
+		})
+	}
+}
+
+func TestShuffledKeys(t *testing.T) {
+	if keys := ShuffledKeys(map[int]bool(nil)); len(keys) != 0 {
+		t.Fatalf("nil map: got %v", keys)
+	}
+
+	m := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3}
+	// counts[k][i] is the number of times key k was in
+	// position i.
+	var counts [4][4]int
+	for i := 0; i < 40000; i++ {
+		keys := ShuffledKeys(m)
+		if len(keys) != len(m) {
+			t.Fatalf("got %d keys, expected %d", len(keys), len(m))
+		}
+		var seen [4]bool
+		for j, k := range keys {
+			v, ok := m[k]
+			if !ok || seen[v] {
+				t.Fatalf("invalid keys: %v", keys)
+			}
+			seen[v] = true
+			counts[v][j]++
+		}
+	}
+	for k := range counts {
+		checkUniform(t, counts[k][:])
+	}
+}
@@ -1,5 +1,9 @@
 module github.com/ericlagergren/saferand
 
-go 1.17
+go 1.18
 
-require golang.org/x/exp v0.0.0-20211221223016-e29036178569
+require (
+	golang.org/x/crypto v0.24.0
+	golang.org/x/exp v0.0.0-20211221223016-e29036178569
+	golang.org/x/sys v0.21.0
+)
@@ -0,0 +1,311 @@
+package saferand
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+var (
+	errRepetitionCount    = errors.New("saferand: source failed repetition count test")
+	errAdaptiveProportion = errors.New("saferand: source failed adaptive proportion test")
+)
+
+const (
+	// healthAlpha is the false positive probability of the
+	// default health test cutoffs, as log2(alpha).
+	//
+	// SP 800-90B recommends an alpha in [2^-40, 2^-20]. This
+	// uses 2^-40 since a false positive is fatal.
+	healthAlpha = -40
+
+	// defaultRCTCutoff is the default repetition count test
+	// cutoff, 1 + ceil(-log2(alpha)/H) with H = 8 bits of
+	// entropy per byte.
+	defaultRCTCutoff = 1 + (-healthAlpha+7)/8
+
+	// defaultAPTWindow is the default adaptive proportion test
+	// window, as recommended for non-binary sources.
+	defaultAPTWindow = 512
+
+	// healthStartupSamples is the number of samples tested and
+	// discarded before the first output.
+	healthStartupSamples = 1024
+)
+
+// HealthConfig configures the health tests run by
+// NewHealthCheckedSourceConfig.
+//
+// A zero field selects the default.
+type HealthConfig struct {
+	// RCTCutoff is the number of consecutive identical bytes
+	// that fails the repetition count test.
+	//
+	// The default is 6.
+	RCTCutoff int
+	// APTWindow is the number of bytes in each adaptive
+	// proportion test window.
+	//
+	// The default is 512.
+	APTWindow int
+	// APTCutoff is the number of occurrences of the first
+	// byte in a window that fails the adaptive proportion test.
+	//
+	// The default is computed from APTWindow so that a healthy
+	// source fails with probability at most 2^-40 per window.
+	APTCutoff int
+}
+
+// healthSource is a Source that runs the SP 800-90B health
+// tests over the bytes from another Source.
+type healthSource struct {
+	mu    sync.Mutex
+	inner Source
+	cfg   HealthConfig
+	// err is the first error encountered. Errors are
+	// permanent.
+	err     error
+	started bool
+	// buf[off:] has been tested but not yet handed out.
+	// buf[:off] has been handed out and zeroed.
+	buf [256]byte
+	off int
+
+	// Repetition count test state.
+	rctLast  byte
+	rctCount int
+
+	// Adaptive proportion test state.
+	aptFirst byte
+	aptCount int
+	aptIndex int
+}
+
+var (
+	_ Source    = (*healthSource)(nil)
+	_ TrySource = (*healthSource)(nil)
+	_ io.Reader = (*healthSource)(nil)
+)
+
+// NewHealthCheckedSource returns a Source that runs the NIST
+// SP 800-90B health tests over the bytes read from inner.
+//
+// It is equivalent to NewHealthCheckedSourceConfig with a zero
+// HealthConfig.
+func NewHealthCheckedSource(inner Source) Source {
+	return NewHealthCheckedSourceConfig(inner, HealthConfig{})
+}
+
+// NewHealthCheckedSourceConfig returns a Source that runs the
+// NIST SP 800-90B health tests over the bytes read from inner.
+//
+// Each byte read from inner is treated as one sample and fed
+// through the Repetition Count Test (section 4.4.1), which
+// detects a stuck source, and the Adaptive Proportion Test
+// (section 4.4.2), which detects a loss of entropy. Before its
+// first output, the Source runs the startup tests over 1024
+// samples, which are then discarded.
+//
+// If inner implements io.Reader, its bytes are read directly.
+// Otherwise, they are read using Uint64.
+//
+// Once a test fails, the Source permanently fails. Its Int63
+// and Uint64 methods panic, and its TryInt63, TryUint64, and
+// Read methods return an error.
+//
+// The returned Source is safe for concurrent use by multiple
+// goroutines.
+func NewHealthCheckedSourceConfig(inner Source, cfg HealthConfig) Source {
+	if cfg.RCTCutoff <= 0 {
+		cfg.RCTCutoff = defaultRCTCutoff
+	}
+	if cfg.APTWindow <= 0 {
+		cfg.APTWindow = defaultAPTWindow
+	}
+	if cfg.APTCutoff <= 0 {
+		cfg.APTCutoff = aptCutoff(cfg.APTWindow)
+	}
+	s := &healthSource{
+		inner: inner,
+		cfg:   cfg,
+	}
+	s.off = len(s.buf)
+	return s
+}
+
+// aptCutoff returns the smallest cutoff c such that a window
+// of full entropy bytes contains c or more copies of its first
+// byte with probability at most 2^healthAlpha.
+func aptCutoff(window int) int {
+	// The first byte is always counted, and each of the
+	// remaining n bytes matches it with probability p.
+	n := window - 1
+	p := 1.0 / 256
+	lp, lq := math.Log(p), math.Log1p(-p)
+	ln, _ := math.Lgamma(float64(n + 1))
+	limit := math.Ldexp(1, healthAlpha)
+
+	// Sum the upper tail P(X >= k) from the top.
+	var tail float64
+	for k := n; k >= 0; k-- {
+		lk, _ := math.Lgamma(float64(k + 1))
+		lnk, _ := math.Lgamma(float64(n - k + 1))
+		tail += math.Exp(ln - lk - lnk + float64(k)*lp + float64(n-k)*lq)
+		if tail > limit {
+			// P(X >= k+1) <= limit, so fail when the count
+			// (including the first byte) reaches k+2.
+			return k + 2
+		}
+	}
+	return 1
+}
+
+func (s *healthSource) Seed(seed uint64) {
+	s.inner.Seed(seed)
+}
+
+func (s *healthSource) Int63() int64 {
+	x, err := s.TryInt63()
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+func (s *healthSource) TryInt63() (int64, error) {
+	x, err := s.TryUint64()
+	return int64(x >> 1), err
+}
+
+func (s *healthSource) Uint64() uint64 {
+	x, err := s.TryUint64()
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+func (s *healthSource) TryUint64() (uint64, error) {
+	var b [8]byte
+	if _, err := s.Read(b[:]); err != nil {
+		return 0, err
+	}
+	x := binary.LittleEndian.Uint64(b[:])
+	wipe(b[:])
+	return x, nil
+}
+
+// Read fills p with random bytes that have passed the health
+// tests.
+//
+// It always returns len(p) and a nil error, or zero and
+// a non-nil error.
+func (s *healthSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return 0, s.err
+	}
+	for n := 0; n < len(p); {
+		if s.off == len(s.buf) {
+			if err := s.fill(); err != nil {
+				s.err = err
+				wipe(p)
+				return 0, err
+			}
+		}
+		c := copy(p[n:], s.buf[s.off:])
+		wipe(s.buf[s.off : s.off+c])
+		s.off += c
+		n += c
+	}
+	return len(p), nil
+}
+
+// fill refills the buffer with tested bytes from the inner
+// Source, running the startup tests first if necessary.
+//
+// s.mu must be held.
+func (s *healthSource) fill() error {
+	if !s.started {
+		var tmp [healthStartupSamples]byte
+		err := s.readInner(tmp[:])
+		if err == nil {
+			err = s.test(tmp[:])
+		}
+		wipe(tmp[:])
+		if err != nil {
+			return err
+		}
+		s.started = true
+	}
+	if err := s.readInner(s.buf[:]); err != nil {
+		return err
+	}
+	if err := s.test(s.buf[:]); err != nil {
+		wipe(s.buf[:])
+		return err
+	}
+	s.off = 0
+	return nil
+}
+
+// readInner fills p from the inner Source.
+func (s *healthSource) readInner(p []byte) error {
+	if r, ok := s.inner.(io.Reader); ok {
+		_, err := io.ReadFull(r, p)
+		return err
+	}
+	for i := 0; i < len(p); i += 8 {
+		var x uint64
+		if ts, ok := s.inner.(TrySource); ok {
+			var err error
+			x, err = ts.TryUint64()
+			if err != nil {
+				return err
+			}
+		} else {
+			x = s.inner.Uint64()
+		}
+		for j := i; j < i+8 && j < len(p); j++ {
+			p[j] = byte(x)
+			x >>= 8
+		}
+	}
+	return nil
+}
+
+// test runs the continuous health tests over each sample in p.
+func (s *healthSource) test(p []byte) error {
+	for _, b := range p {
+		// Repetition Count Test.
+		if s.rctCount > 0 && b == s.rctLast {
+			s.rctCount++
+			if s.rctCount >= s.cfg.RCTCutoff {
+				return errRepetitionCount
+			}
+		} else {
+			s.rctLast = b
+			s.rctCount = 1
+		}
+
+		// Adaptive Proportion Test.
+		if s.aptIndex == 0 {
+			s.aptFirst = b
+			s.aptCount = 1
+		} else if b == s.aptFirst {
+			s.aptCount++
+			if s.aptCount >= s.cfg.APTCutoff {
+				return errAdaptiveProportion
+			}
+		}
+		s.aptIndex++
+		if s.aptIndex == s.cfg.APTWindow {
+			s.aptIndex = 0
+		}
+	}
+	return nil
+}
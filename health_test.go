@@ -0,0 +1,115 @@
+package saferand
+
+import (
+	"errors"
+	"testing"
+)
+
+// zeroReader is a stuck entropy source.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// biasedReader is an entropy source that never repeats a byte,
+// but where every other byte is zero.
+type biasedReader struct {
+	i int
+}
+
+func (r *biasedReader) Read(p []byte) (int, error) {
+	for i := range p {
+		if r.i%2 == 0 {
+			p[i] = 0
+		} else {
+			p[i] = byte(r.i%255 + 1)
+		}
+		r.i++
+	}
+	return len(p), nil
+}
+
+func TestHealthCheckedSourceStuck(t *testing.T) {
+	src := NewHealthCheckedSource(NewSourceFromReader(zeroReader{}))
+	_, err := src.(TrySource).TryUint64()
+	if !errors.Is(err, errRepetitionCount) {
+		t.Fatalf("expected %v, got %v", errRepetitionCount, err)
+	}
+	// Failures are permanent.
+	if _, err := src.(TrySource).TryInt63(); err == nil {
+		t.Fatal("expected an error")
+	}
+	checkPanics(t, "Uint64", func() { src.Uint64() })
+}
+
+func TestHealthCheckedSourceBiased(t *testing.T) {
+	src := NewHealthCheckedSource(NewSourceFromReader(&biasedReader{}))
+	_, err := src.(TrySource).TryUint64()
+	if !errors.Is(err, errAdaptiveProportion) {
+		t.Fatalf("expected %v, got %v", errAdaptiveProportion, err)
+	}
+}
+
+// TestHealthCheckedSourceNotReader tests wrapping a Source that
+// does not implement io.Reader.
+func TestHealthCheckedSourceNotReader(t *testing.T) {
+	src := NewHealthCheckedSource(fixedSource(0x0101010101010101))
+	checkPanics(t, "Uint64", func() { src.Uint64() })
+}
+
+func TestHealthCheckedSourceConfig(t *testing.T) {
+	// With a large enough cutoff, even a stuck source passes
+	// the repetition count test.
+	src := NewHealthCheckedSourceConfig(NewSourceFromReader(zeroReader{}),
+		HealthConfig{RCTCutoff: 1 << 30, APTWindow: 1 << 20, APTCutoff: 1 << 30})
+	if x, err := src.(TrySource).TryUint64(); err != nil || x != 0 {
+		t.Fatalf("got (%d, %v), expected (0, nil)", x, err)
+	}
+
+	// A small window catches the biased source sooner.
+	src = NewHealthCheckedSourceConfig(NewSourceFromReader(&biasedReader{}),
+		HealthConfig{APTWindow: 16})
+	hs := src.(*healthSource)
+	if hs.cfg.APTCutoff >= aptCutoff(defaultAPTWindow) {
+		t.Fatalf("unexpectedly large cutoff: %d", hs.cfg.APTCutoff)
+	}
+	if _, err := hs.TryUint64(); !errors.Is(err, errAdaptiveProportion) {
+		t.Fatalf("expected %v, got %v", errAdaptiveProportion, err)
+	}
+}
+
+func TestHealthCheckedSourceHealthy(t *testing.T) {
+	src := NewHealthCheckedSource(NewSource())
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x, err := src.(TrySource).TryUint64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		hi[x>>60]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+
+	r := NewWithSource(src)
+	if _, err := r.Read(make([]byte, 10000)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAPTCutoff(t *testing.T) {
+	// Check the defaults against a direct computation. With
+	// alpha = 2^-40, a window of 512 bytes fails once the first
+	// byte appears 20 times.
+	if got := aptCutoff(defaultAPTWindow); got != 20 {
+		t.Fatalf("got %d, expected 20", got)
+	}
+	if defaultRCTCutoff != 6 {
+		t.Fatalf("got %d, expected 6", defaultRCTCutoff)
+	}
+}
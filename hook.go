@@ -0,0 +1,47 @@
+package saferand
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// override holds the readerBox installed by SetReader.
+var override atomic.Value
+
+// readerBox allows storing a nil io.Reader in override.
+type readerBox struct {
+	r io.Reader
+}
+
+// overrideReader returns the io.Reader installed by
+// SetReader, or nil if there is none.
+func overrideReader() io.Reader {
+	b, _ := override.Load().(readerBox)
+	return b.r
+}
+
+// SetReader replaces crypto/rand with r as the source of
+// entropy for Read, ReadContext, ReadDeadline, Reader, Stream,
+// the Try functions, the other package-level functions, and
+// the Rands returned by New, and returns a function that
+// restores the previous reader.
+//
+// SetReader is intended for tests only: it makes every caller
+// of this package in the process predictable. A typical use
+// is
+//
+//    defer saferand.SetReader(r)()
+//
+// If r is nil, crypto/rand is used. The Sources returned by
+// NewBufferedSource, NewChaChaSource, and NewCTRDRBGSource are
+// not affected.
+//
+// SetReader is safe to call concurrently with the rest of the
+// package, but r must be safe for concurrent use if the
+// package is used by multiple goroutines while it is
+// installed.
+func SetReader(r io.Reader) (restore func()) {
+	prev := overrideReader()
+	override.Store(readerBox{r: r})
+	return func() { override.Store(readerBox{r: prev}) }
+}
@@ -0,0 +1,104 @@
+package saferand
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestSetReader(t *testing.T) {
+	buf := make([]byte, 64)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+
+	restore := SetReader(bytes.NewReader(buf))
+	if got, want := Uint64(), uint64(0x0706050403020100); got != want {
+		t.Fatalf("Uint64: expected %#x, got %#x", want, got)
+	}
+	if got, want := New().Uint64(), uint64(0x0f0e0d0c0b0a0908); got != want {
+		t.Fatalf("New().Uint64: expected %#x, got %#x", want, got)
+	}
+	p := make([]byte, 4)
+	if _, err := Read(p); err != nil || !bytes.Equal(p, buf[16:20]) {
+		t.Fatalf("Read: got (%x, %v)", p, err)
+	}
+	if _, err := Reader.Read(p); err != nil || !bytes.Equal(p, buf[20:24]) {
+		t.Fatalf("Reader.Read: got (%x, %v)", p, err)
+	}
+
+	// A reader set later takes precedence until it is
+	// restored.
+	zero := SetReader(bytes.NewReader(make([]byte, 8)))
+	if x := Uint64(); x != 0 {
+		t.Fatalf("Uint64: expected 0, got %#x", x)
+	}
+	zero()
+	if got, want := Uint64(), uint64(0x1f1e1d1c1b1a1918); got != want {
+		t.Fatalf("Uint64 after restore: expected %#x, got %#x", want, got)
+	}
+
+	restore()
+	if overrideReader() != nil {
+		t.Fatal("restore did not remove the reader")
+	}
+	// buf is nearly exhausted, so these would panic or fail
+	// if they still used it.
+	for i := 0; i < 10; i++ {
+		Uint64()
+	}
+	if _, err := Read(make([]byte, 64)); err != nil {
+		t.Fatalf("Read after restore: %v", err)
+	}
+	if Uint64() == Uint64() {
+		t.Fatal("two successive calls returned the same value")
+	}
+}
+
+func TestSetReaderReadContext(t *testing.T) {
+	buf := make([]byte, 16)
+	for i := range buf {
+		buf[i] = byte(i + 1)
+	}
+	defer SetReader(bytes.NewReader(buf))()
+
+	p := make([]byte, 8)
+	if _, err := ReadContext(context.Background(), p); err != nil || !bytes.Equal(p, buf[:8]) {
+		t.Fatalf("ReadContext: got (%x, %v)", p, err)
+	}
+	if _, err := ReadDeadline(p, time.Minute); err != nil || !bytes.Equal(p, buf[8:]) {
+		t.Fatalf("ReadDeadline: got (%x, %v)", p, err)
+	}
+	if _, err := ReadContext(context.Background(), p); err != io.EOF {
+		t.Fatalf("ReadContext: expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestSetReaderDeterministic(t *testing.T) {
+	seq := func() []uint64 {
+		defer SetReader(bytes.NewReader(bytes.Repeat([]byte{1, 2, 3}, 100)))()
+		vals := make([]uint64, 10)
+		for i := range vals {
+			vals[i] = Uint64()
+		}
+		return vals
+	}
+	a, b := seq(), seq()
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("#%d: %#x != %#x", i, a[i], b[i])
+		}
+	}
+}
+
+func TestSetReaderNil(t *testing.T) {
+	restore := SetReader(bytes.NewReader(nil))
+	defer restore()
+
+	SetReader(nil)
+	if _, err := Read(make([]byte, 8)); err != nil {
+		t.Fatalf("Read with nil reader: %v", err)
+	}
+}
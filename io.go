@@ -0,0 +1,199 @@
+package saferand
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// ReadContext is like Read, but returns early if ctx is done
+// before the read completes.
+//
+// crypto/rand can block on some platforms, such as early in
+// the boot process before the OS entropy pool is seeded. Like
+// Read, ReadContext reads from the reader installed by
+// SetReader, if any.
+//
+// If ctx is done first, ReadContext zeroes p and returns
+// ctx.Err(). The underlying read continues in the background;
+// its result is discarded and zeroed once it completes.
+func ReadContext(ctx context.Context, p []byte) (int, error) {
+	return readContext(ctx, ExpSource{}, p)
+}
+
+func readContext(ctx context.Context, r io.Reader, p []byte) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+	// Read into a private buffer so that an abandoned read
+	// cannot write to p after we return.
+	buf := make([]byte, len(p))
+	ch := make(chan result)
+	go func() {
+		n, err := io.ReadFull(r, buf)
+		select {
+		case ch <- result{n, err}:
+		case <-ctx.Done():
+			wipe(buf)
+		}
+	}()
+
+	select {
+	case res := <-ch:
+		n := copy(p, buf[:res.n])
+		wipe(buf)
+		return n, res.err
+	case <-ctx.Done():
+		wipe(p)
+		return 0, ctx.Err()
+	}
+}
+
+// ReadDeadline is like Read, but returns early if the read does
+// not complete within d.
+//
+// If d elapses first, ReadDeadline zeroes p and returns
+// context.DeadlineExceeded. As with ReadContext, the
+// underlying read from the operating system may still be in
+// flight after ReadDeadline returns; its result is discarded
+// and zeroed once it completes.
+func ReadDeadline(p []byte, d time.Duration) (int, error) {
+	return readDeadline(ExpSource{}, p, d)
+}
+
+func readDeadline(r io.Reader, p []byte, d time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return readContext(ctx, r, p)
+}
+
+// writeToBufferSize is the size of the buffer used by WriteTo.
+const writeToBufferSize = 32 << 10
+
+// WriteTo writes n random bytes to w.
+//
+// It returns the number of bytes written and the first error
+// encountered, if any. Like io.Copy, it returns
+// io.ErrShortWrite if w writes fewer bytes than requested
+// without returning an error.
+//
+// The bytes are generated in chunks using a buffer of at most
+// 32 KiB, which is zeroed before WriteTo returns.
+//
+// It panics if n < 0.
+func WriteTo(w io.Writer, n int64) (int64, error) {
+	if n < 0 {
+		panic("invalid argument to WriteTo")
+	}
+	size := int64(writeToBufferSize)
+	if n < size {
+		size = n
+	}
+	buf := make([]byte, size)
+	defer wipe(buf)
+	return copyRandom(w, n, buf)
+}
+
+// copyBufPool holds *[writeToBufferSize]byte buffers for
+// CopyN.
+var copyBufPool = sync.Pool{
+	New: func() interface{} { return new([writeToBufferSize]byte) },
+}
+
+// CopyN writes n random bytes to w.
+//
+// It has the same signature as io.CopyN and is a drop-in
+// replacement for io.CopyN(w, Reader, n). It returns the number
+// of bytes written and the first error encountered, if any. It
+// returns io.ErrShortWrite if w writes fewer bytes than
+// requested without returning an error. Like io.CopyN, it
+// writes nothing and returns a nil error if n <= 0.
+//
+// Unlike WriteTo, it reuses buffers across calls. They are
+// zeroed before they are reused.
+func CopyN(w io.Writer, n int64) (int64, error) {
+	if n <= 0 {
+		return 0, nil
+	}
+	buf := copyBufPool.Get().(*[writeToBufferSize]byte)
+	defer func() {
+		wipe(buf[:])
+		copyBufPool.Put(buf)
+	}()
+	return copyRandom(w, n, buf[:])
+}
+
+// copyRandom writes n random bytes to w, generating them in
+// chunks of len(buf) bytes.
+func copyRandom(w io.Writer, n int64, buf []byte) (int64, error) {
+	var written int64
+	for written < n {
+		chunk := buf
+		if rem := n - written; rem < int64(len(chunk)) {
+			chunk = chunk[:rem]
+		}
+		if _, err := Read(chunk); err != nil {
+			return written, err
+		}
+		m, err := w.Write(chunk)
+		written += int64(m)
+		if err != nil {
+			return written, err
+		}
+		if m != len(chunk) {
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
+
+// uint64ChanBatch is the number of values Uint64Chan generates
+// at once.
+const uint64ChanBatch = 64
+
+// Uint64Chan returns a channel of random uint64s with a buffer
+// of bufSize values.
+//
+// A background goroutine generates the values in batches and
+// sends them on the channel. When ctx is done, the goroutine
+// discards any values it has not sent, closes the channel, and
+// exits. Buffered values can still be received until the
+// channel is drained.
+//
+// Like Uint64, the goroutine responds to read failures
+// according to the current FailurePolicy. The default policy
+// panics, which crashes the program.
+//
+// It panics if bufSize < 0.
+func Uint64Chan(ctx context.Context, bufSize int) <-chan uint64 {
+	if bufSize < 0 {
+		panic("invalid argument to Uint64Chan")
+	}
+	ch := make(chan uint64, bufSize)
+	go func() {
+		defer close(ch)
+		var batch [uint64ChanBatch]uint64
+		defer func() {
+			for i := range batch {
+				batch[i] = 0
+			}
+		}()
+		for {
+			defaultRand.Uint64s(batch[:])
+			for _, x := range batch {
+				select {
+				case ch <- x:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch
+}
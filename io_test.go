@@ -0,0 +1,263 @@
+package saferand
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+// blockingReader writes half of p, then blocks until unblock
+// is closed.
+type blockingReader struct {
+	unblock chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	n := len(p) / 2
+	for i := range p[:n] {
+		p[i] = 0xaa
+	}
+	<-r.unblock
+	return n, nil
+}
+
+func TestReadContext(t *testing.T) {
+	p := make([]byte, 64)
+	n, err := ReadContext(context.Background(), p)
+	if err != nil || n != len(p) {
+		t.Fatalf("got (%d, %v)", n, err)
+	}
+	if bytes.Equal(p, make([]byte, len(p))) {
+		t.Fatal("ReadContext did not fill p")
+	}
+}
+
+func TestReadContextCancel(t *testing.T) {
+	r := &blockingReader{unblock: make(chan struct{})}
+	defer close(r.unblock)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	p := bytes.Repeat([]byte{0xff}, 64)
+	n, err := readContext(ctx, r, p)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if n != 0 {
+		t.Fatalf("expected 0 bytes, got %d", n)
+	}
+	for i, c := range p {
+		if c != 0 {
+			t.Fatalf("byte %d not zeroed: %#x", i, c)
+		}
+	}
+}
+
+func TestReadContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReadContext(ctx, make([]byte, 8)); err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
+
+func TestReadDeadline(t *testing.T) {
+	p := make([]byte, 64)
+	n, err := ReadDeadline(p, time.Minute)
+	if err != nil || n != len(p) {
+		t.Fatalf("got (%d, %v)", n, err)
+	}
+	if bytes.Equal(p, make([]byte, len(p))) {
+		t.Fatal("ReadDeadline did not fill p")
+	}
+}
+
+func TestReadDeadlineTimeout(t *testing.T) {
+	r := &blockingReader{unblock: make(chan struct{})}
+	defer close(r.unblock)
+
+	p := bytes.Repeat([]byte{0xff}, 64)
+	start := time.Now()
+	n, err := readDeadline(r, p, 10*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("ReadDeadline took %v", d)
+	}
+	if n != 0 {
+		t.Fatalf("expected 0 bytes, got %d", n)
+	}
+	for i, c := range p {
+		if c != 0 {
+			t.Fatalf("byte %d not zeroed: %#x", i, c)
+		}
+	}
+}
+
+func TestReadDeadlineExpired(t *testing.T) {
+	p := bytes.Repeat([]byte{0xff}, 8)
+	if _, err := ReadDeadline(p, 0); err != context.DeadlineExceeded {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+// limitWriter accepts up to n bytes, then returns err.
+type limitWriter struct {
+	n   int
+	err error
+	buf bytes.Buffer
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		w.buf.Write(p[:w.n])
+		m := w.n
+		w.n = 0
+		return m, w.err
+	}
+	w.n -= len(p)
+	return w.buf.Write(p)
+}
+
+func TestWriteTo(t *testing.T) {
+	for _, n := range []int64{0, 1, 1000, writeToBufferSize, 3*writeToBufferSize + 17} {
+		var buf bytes.Buffer
+		m, err := WriteTo(&buf, n)
+		if err != nil || m != n {
+			t.Fatalf("WriteTo(%d): got (%d, %v)", n, m, err)
+		}
+		if int64(buf.Len()) != n {
+			t.Fatalf("WriteTo(%d): wrote %d bytes", n, buf.Len())
+		}
+		if n >= 1000 && bytes.Count(buf.Bytes(), []byte{0}) > int(n/64) {
+			t.Fatalf("WriteTo(%d): too many zero bytes", n)
+		}
+	}
+
+	restore := SetReader(bytes.NewReader(bytes.Repeat([]byte{1, 2, 3, 4}, 1e5)))
+	var buf bytes.Buffer
+	if _, err := WriteTo(&buf, 2*writeToBufferSize+3); err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	if !bytes.Equal(buf.Bytes(), bytes.Repeat([]byte{1, 2, 3, 4}, 1e5)[:2*writeToBufferSize+3]) {
+		t.Fatal("WriteTo did not write the bytes that were read")
+	}
+
+	checkPanics(t, "WriteTo(w, -1)", func() { WriteTo(io.Discard, -1) })
+}
+
+func TestWriteToErrors(t *testing.T) {
+	errWrite := errors.New("write failed")
+	w := &limitWriter{n: writeToBufferSize + 5, err: errWrite}
+	n, err := WriteTo(w, 4*writeToBufferSize)
+	if err != errWrite {
+		t.Fatalf("expected %v, got %v", errWrite, err)
+	}
+	if n != writeToBufferSize+5 || int64(w.buf.Len()) != n {
+		t.Fatalf("got %d bytes written (%d buffered), expected %d",
+			n, w.buf.Len(), writeToBufferSize+5)
+	}
+
+	w = &limitWriter{n: 10}
+	if n, err := WriteTo(w, 20); err != io.ErrShortWrite || n != 10 {
+		t.Fatalf("short write: got (%d, %v)", n, err)
+	}
+
+	restore := SetReader(iotest.ErrReader(io.ErrUnexpectedEOF))
+	defer restore()
+	if n, err := WriteTo(io.Discard, 10); err != io.ErrUnexpectedEOF || n != 0 {
+		t.Fatalf("read error: got (%d, %v)", n, err)
+	}
+}
+
+func TestCopyN(t *testing.T) {
+	for _, n := range []int64{0, 1, 1000, writeToBufferSize, 3*writeToBufferSize + 17} {
+		var buf bytes.Buffer
+		m, err := CopyN(&buf, n)
+		if err != nil || m != n {
+			t.Fatalf("CopyN(%d): got (%d, %v)", n, m, err)
+		}
+		if int64(buf.Len()) != n {
+			t.Fatalf("CopyN(%d): wrote %d bytes", n, buf.Len())
+		}
+		if n >= 1000 && bytes.Count(buf.Bytes(), []byte{0}) > int(n/64) {
+			t.Fatalf("CopyN(%d): too many zero bytes", n)
+		}
+	}
+	if m, err := CopyN(io.Discard, -1); m != 0 || err != nil {
+		t.Fatalf("CopyN(w, -1): got (%d, %v)", m, err)
+	}
+
+	errWrite := errors.New("write failed")
+	w := &limitWriter{n: writeToBufferSize + 5, err: errWrite}
+	if n, err := CopyN(w, 4*writeToBufferSize); err != errWrite || n != writeToBufferSize+5 {
+		t.Fatalf("write error: got (%d, %v)", n, err)
+	}
+	w = &limitWriter{n: 10}
+	if n, err := CopyN(w, 20); err != io.ErrShortWrite || n != 10 {
+		t.Fatalf("short write: got (%d, %v)", n, err)
+	}
+
+	restore := SetReader(iotest.ErrReader(io.ErrUnexpectedEOF))
+	defer restore()
+	if n, err := CopyN(io.Discard, 10); err != io.ErrUnexpectedEOF || n != 0 {
+		t.Fatalf("read error: got (%d, %v)", n, err)
+	}
+}
+
+func TestUint64Chan(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := Uint64Chan(ctx, 16)
+	var hi [16]int
+	seen := make(map[uint64]bool)
+	for i := 0; i < 10000; i++ {
+		x := <-ch
+		if seen[x] {
+			t.Fatalf("#%d: repeated value %#x", i, x)
+		}
+		seen[x] = true
+		hi[x>>60]++
+	}
+	checkUniform(t, hi[:])
+	cancel()
+
+	// The channel is closed once the buffer drains.
+	timeout := time.After(5 * time.Second)
+	for open := true; open; {
+		select {
+		case _, open = <-ch:
+		case <-timeout:
+			t.Fatal("channel not closed after cancel")
+		}
+	}
+
+	// The producer exits.
+	for runtime.NumGoroutine() > before {
+		select {
+		case <-timeout:
+			t.Fatalf("goroutine leaked: %d > %d", runtime.NumGoroutine(), before)
+		default:
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// An unbuffered channel works too.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	ch = Uint64Chan(ctx, 0)
+	if a, b := <-ch, <-ch; a == b {
+		t.Fatalf("repeated value %#x", a)
+	}
+
+	checkPanics(t, "Uint64Chan(ctx, -1)", func() { Uint64Chan(ctx, -1) })
+}
@@ -0,0 +1,77 @@
+package saferand
+
+import (
+	"net"
+)
+
+// IPv4 returns a random IPv4 address.
+func IPv4() net.IP { return defaultRand.IPv4() }
+
+// IPv6 returns a random IPv6 address.
+func IPv6() net.IP { return defaultRand.IPv6() }
+
+// IPInCIDR returns a uniform random address in n.
+//
+// It panics if n is not a valid IPv4 or IPv6 network.
+func IPInCIDR(n *net.IPNet) net.IP { return defaultRand.IPInCIDR(n) }
+
+// MAC returns a random unicast, locally administered MAC
+// address.
+func MAC() net.HardwareAddr { return defaultRand.MAC() }
+
+// IPv4 returns a random IPv4 address.
+//
+// The result is 4 bytes long. Every address, including
+// reserved and special purpose addresses, is equally likely.
+func (r *Rand) IPv4() net.IP {
+	return net.IP(r.Bytes(net.IPv4len))
+}
+
+// IPv6 returns a random IPv6 address.
+//
+// The result is 16 bytes long. Every address, including
+// reserved, special purpose, and IPv4-mapped addresses, is
+// equally likely.
+func (r *Rand) IPv6() net.IP {
+	return net.IP(r.Bytes(net.IPv6len))
+}
+
+// IPInCIDR returns a uniform random address in n.
+//
+// The network bits of the result are copied from n.IP and the
+// host bits are random. The result is 4 bytes long for an IPv4
+// network and 16 bytes long for an IPv6 network.
+//
+// It panics if n is not a valid IPv4 or IPv6 network.
+func (r *Rand) IPInCIDR(n *net.IPNet) net.IP {
+	ip := n.IP
+	if len(n.Mask) == net.IPv4len {
+		ip = ip.To4()
+	}
+	if ip == nil || len(ip) != len(n.Mask) {
+		panic("invalid argument to IPInCIDR")
+	}
+	if _, bits := n.Mask.Size(); bits == 0 {
+		// Non-canonical mask.
+		panic("invalid argument to IPInCIDR")
+	}
+	out := r.Bytes(len(ip))
+	for i := range out {
+		out[i] = ip[i]&n.Mask[i] | out[i]&^n.Mask[i]
+	}
+	return out
+}
+
+// MAC returns a random unicast, locally administered MAC
+// address.
+//
+// The result is 6 bytes long. In the first byte, the
+// universal/local bit (0x02) is set and the
+// individual/group bit (0x01) is cleared, so the address
+// cannot collide with a vendor-assigned address. The other 46
+// bits are random.
+func (r *Rand) MAC() net.HardwareAddr {
+	mac := r.Bytes(6)
+	mac[0] = mac[0]&^0x01 | 0x02
+	return net.HardwareAddr(mac)
+}
@@ -0,0 +1,125 @@
+package saferand
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestIPv4(t *testing.T) {
+	ip := IPv4()
+	if len(ip) != net.IPv4len || ip.To4() == nil {
+		t.Fatalf("invalid IPv4 address: %v", ip)
+	}
+	if IPv4().Equal(IPv4()) {
+		t.Fatal("successive calls returned the same address")
+	}
+}
+
+func TestIPv6(t *testing.T) {
+	ip := IPv6()
+	if len(ip) != net.IPv6len {
+		t.Fatalf("invalid IPv6 address: %v", ip)
+	}
+	if IPv6().Equal(IPv6()) {
+		t.Fatal("successive calls returned the same address")
+	}
+}
+
+func TestMAC(t *testing.T) {
+	var or, and byte = 0, 0xff
+	for i := 0; i < 1000; i++ {
+		mac := MAC()
+		if len(mac) != 6 {
+			t.Fatalf("invalid MAC address: %v", mac)
+		}
+		if mac[0]&0x02 == 0 {
+			t.Fatalf("%v: universal/local bit is clear", mac)
+		}
+		if mac[0]&0x01 != 0 {
+			t.Fatalf("%v: individual/group bit is set", mac)
+		}
+		or |= mac[0]
+		and &= mac[0]
+	}
+	// The other bits of the first byte are random.
+	if or != 0xfe || and != 0x02 {
+		t.Fatalf("first byte: or=%#x and=%#x", or, and)
+	}
+	if bytes.Equal(MAC(), MAC()) {
+		t.Fatal("successive calls returned the same address")
+	}
+}
+
+func TestIPInCIDR(t *testing.T) {
+	for _, s := range []string{
+		"0.0.0.0/0",
+		"10.0.0.0/8",
+		"192.168.1.0/24",
+		"172.16.0.0/12",
+		"203.0.113.7/31",
+		"203.0.113.7/32",
+		"::/0",
+		"2001:db8::/32",
+		"2001:db8:1234:5678::/61",
+		"fe80::1/127",
+		"fe80::1/128",
+	} {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ones, bits := n.Mask.Size()
+
+		// set[i] counts how often bit i of the address is set.
+		set := make([]int, bits)
+		const samples = 2000
+		for i := 0; i < samples; i++ {
+			ip := IPInCIDR(n)
+			if len(ip)*8 != bits {
+				t.Fatalf("%s: got %d byte address", s, len(ip))
+			}
+			if !n.Contains(ip) {
+				t.Fatalf("%s: %v not in network", s, ip)
+			}
+			for j := range set {
+				if ip[j/8]&(0x80>>(j%8)) != 0 {
+					set[j]++
+				}
+			}
+		}
+		for j, c := range set {
+			if j < ones {
+				// Network bits match the prefix.
+				want := 0
+				if n.IP[j/8]&(0x80>>(j%8)) != 0 {
+					want = samples
+				}
+				if c != want {
+					t.Fatalf("%s: network bit %d set %d times", s, j, c)
+				}
+			} else if c < samples/4 || c > 3*samples/4 {
+				// Host bits are random.
+				t.Fatalf("%s: host bit %d set %d times", s, j, c)
+			}
+		}
+	}
+
+	// IPv4 networks with 16 byte addresses are allowed.
+	n := &net.IPNet{
+		IP:   net.ParseIP("198.51.100.0"),
+		Mask: net.CIDRMask(24, 32),
+	}
+	if ip := IPInCIDR(n); len(ip) != net.IPv4len || !n.Contains(ip) {
+		t.Fatalf("invalid address: %v", ip)
+	}
+
+	for _, n := range []*net.IPNet{
+		{IP: net.ParseIP("2001:db8::"), Mask: net.CIDRMask(24, 32)},
+		{IP: net.IPv4(1, 2, 3, 4).To4(), Mask: net.CIDRMask(64, 128)},
+		{IP: net.IPv4(1, 2, 3, 4).To4(), Mask: net.IPv4Mask(0xff, 0, 0xff, 0)},
+		{IP: nil, Mask: net.CIDRMask(8, 32)},
+	} {
+		checkPanics(t, n.String(), func() { IPInCIDR(n) })
+	}
+}
@@ -0,0 +1,127 @@
+package saferand
+
+import (
+	"encoding/binary"
+	"io"
+	"sync"
+)
+
+// mixedSource is a Source that XORs the output of multiple
+// readers.
+type mixedSource struct {
+	mu      sync.Mutex
+	readers []io.Reader
+	// scratch holds the output of one reader before it is
+	// mixed in.
+	scratch [256]byte
+}
+
+var (
+	_ Source    = (*mixedSource)(nil)
+	_ TrySource = (*mixedSource)(nil)
+	_ io.Reader = (*mixedSource)(nil)
+)
+
+// NewMixedSource returns a Source that reads the same number of
+// bytes from each reader and XORs them together.
+//
+// If the readers are independent, the output is at least as
+// unpredictable as the most unpredictable reader. For example,
+// crypto/rand.Reader can be mixed with a hardware RNG device.
+//
+// If a reader fails, its output is discarded and the remaining
+// readers are used for that read. The failed reader is tried
+// again on the next read. The Source only fails if every reader
+// fails: Int63 and Uint64 panic, and Read, TryInt63, and
+// TryUint64 return the last error.
+//
+// The returned Source is safe for concurrent use by multiple
+// goroutines. Reads are serialized, so the readers need not be.
+//
+// It panics if no readers are provided.
+func NewMixedSource(readers ...io.Reader) Source {
+	if len(readers) == 0 {
+		panic("invalid argument to NewMixedSource")
+	}
+	return &mixedSource{
+		readers: append([]io.Reader(nil), readers...),
+	}
+}
+
+func (*mixedSource) Seed(_ uint64) {}
+
+// Read fills p with the XOR of the readers' output.
+//
+// It always returns len(p) and a nil error, or zero and a
+// non-nil error.
+func (s *mixedSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range p {
+		p[i] = 0
+	}
+	for off := 0; off < len(p); off += len(s.scratch) {
+		chunk := p[off:]
+		if len(chunk) > len(s.scratch) {
+			chunk = chunk[:len(s.scratch)]
+		}
+		if err := s.mix(chunk); err != nil {
+			wipe(p)
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// mix XORs the output of each reader into p, which must be no
+// larger than s.scratch.
+//
+// s.mu must be held.
+func (s *mixedSource) mix(p []byte) error {
+	tmp := s.scratch[:len(p)]
+	defer wipe(tmp)
+
+	var err error
+	ok := false
+	for _, r := range s.readers {
+		if _, err = io.ReadFull(r, tmp); err != nil {
+			continue
+		}
+		for i := range p {
+			p[i] ^= tmp[i]
+		}
+		ok = true
+	}
+	if !ok {
+		return err
+	}
+	return nil
+}
+
+func (s *mixedSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *mixedSource) TryInt63() (int64, error) {
+	x, err := s.TryUint64()
+	return int64(x >> 1), err
+}
+
+func (s *mixedSource) Uint64() uint64 {
+	x, err := s.TryUint64()
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+func (s *mixedSource) TryUint64() (uint64, error) {
+	var b [8]byte
+	if _, err := s.Read(b[:]); err != nil {
+		return 0, err
+	}
+	x := binary.LittleEndian.Uint64(b[:])
+	wipe(b[:])
+	return x, nil
+}
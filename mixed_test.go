@@ -0,0 +1,94 @@
+package saferand
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"testing"
+	"testing/iotest"
+)
+
+func TestMixedSource(t *testing.T) {
+	a := make([]byte, 1000)
+	b := make([]byte, 1000)
+	for i := range a {
+		a[i] = byte(i)
+		b[i] = byte(i * 7)
+	}
+	src := NewMixedSource(bytes.NewReader(a), bytes.NewReader(b))
+
+	// The first 8 bytes go to Uint64, the rest to Read, which
+	// spans several chunks.
+	x := src.Uint64()
+	var want [8]byte
+	for i := range want {
+		want[i] = a[i] ^ b[i]
+	}
+	if x != binary.LittleEndian.Uint64(want[:]) {
+		t.Fatalf("Uint64: got %#x, expected %#x", x, binary.LittleEndian.Uint64(want[:]))
+	}
+	p := make([]byte, len(a)-8)
+	if n, err := src.(io.Reader).Read(p); n != len(p) || err != nil {
+		t.Fatalf("Read: got (%d, %v)", n, err)
+	}
+	for i, c := range p {
+		if w := a[8+i] ^ b[8+i]; c != w {
+			t.Fatalf("byte %d: got %#x, expected %#x", 8+i, c, w)
+		}
+	}
+}
+
+func TestMixedSourceFailure(t *testing.T) {
+	good := make([]byte, 64)
+	for i := range good {
+		good[i] = byte(i + 1)
+	}
+	src := NewMixedSource(
+		iotest.ErrReader(io.ErrUnexpectedEOF),
+		bytes.NewReader(good),
+	)
+	p := make([]byte, len(good))
+	if _, err := src.(io.Reader).Read(p); err != nil {
+		t.Fatalf("Read with one failing reader: %v", err)
+	}
+	if !bytes.Equal(p, good) {
+		t.Fatal("expected the output of the working reader")
+	}
+
+	// Now both readers fail.
+	p = bytes.Repeat([]byte{0xff}, 8)
+	if n, err := src.(io.Reader).Read(p); n != 0 || err == nil {
+		t.Fatalf("Read with no working readers: got (%d, %v)", n, err)
+	}
+	if !bytes.Equal(p, make([]byte, 8)) {
+		t.Fatal("p not zeroed after failure")
+	}
+	if _, err := src.(TrySource).TryUint64(); err == nil {
+		t.Fatal("TryUint64: expected an error")
+	}
+	checkPanics(t, "Uint64", func() { src.Uint64() })
+	checkPanics(t, "NewMixedSource()", func() { NewMixedSource() })
+}
+
+func TestMixedSourceConcurrent(t *testing.T) {
+	src := NewMixedSource(rand.Reader, rand.Reader)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				src.Uint64()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var hi [16]int
+	for i := 0; i < 50000; i++ {
+		hi[src.Uint64()>>60]++
+	}
+	checkUniform(t, hi[:])
+}
@@ -0,0 +1,61 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"sync/atomic"
+)
+
+// NonceSize is the size in bytes of the nonces returned by
+// NonceGen.
+const NonceSize = 12
+
+// NonceGen generates unique 96-bit nonces for AEADs like
+// AES-GCM and ChaCha20-Poly1305.
+//
+// Each nonce is a random 32-bit prefix followed by a 64-bit
+// big-endian counter. Nonces from the same NonceGen never
+// repeat. Nonces from different NonceGens share a prefix with
+// probability 2^-32, so use a single NonceGen for each key.
+//
+// A NonceGen is safe for concurrent use by multiple
+// goroutines.
+type NonceGen struct {
+	// ctr is accessed atomically and must be 64-bit aligned.
+	ctr    uint64
+	prefix [4]byte
+}
+
+// NewNonceGen returns a NonceGen with a prefix read from
+// crypto/rand.
+//
+// It panics if crypto/rand fails.
+func NewNonceGen() *NonceGen {
+	g := &NonceGen{}
+	if _, err := rand.Read(g.prefix[:]); err != nil {
+		panic(err)
+	}
+	return g
+}
+
+// Next returns the next nonce.
+//
+// It panics after 2^64-1 nonces instead of reusing the
+// counter.
+func (g *NonceGen) Next() [NonceSize]byte {
+	var c uint64
+	for {
+		c = atomic.LoadUint64(&g.ctr)
+		if c == math.MaxUint64 {
+			panic("saferand: NonceGen counter exhausted")
+		}
+		if atomic.CompareAndSwapUint64(&g.ctr, c, c+1) {
+			break
+		}
+	}
+	var nonce [NonceSize]byte
+	copy(nonce[:4], g.prefix[:])
+	binary.BigEndian.PutUint64(nonce[4:], c)
+	return nonce
+}
@@ -0,0 +1,70 @@
+package saferand
+
+import (
+	"bytes"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestNonceGen(t *testing.T) {
+	g := NewNonceGen()
+	seen := make(map[[NonceSize]byte]struct{}, 1e6)
+	first := g.Next()
+	seen[first] = struct{}{}
+	for i := 1; i < 1e6; i++ {
+		n := g.Next()
+		if _, ok := seen[n]; ok {
+			t.Fatalf("#%d: duplicate nonce %x", i, n)
+		}
+		if !bytes.Equal(n[:4], first[:4]) {
+			t.Fatalf("#%d: prefix changed from %x to %x", i, first[:4], n[:4])
+		}
+		seen[n] = struct{}{}
+	}
+
+	if a, b := NewNonceGen().Next(), NewNonceGen().Next(); a == b {
+		t.Fatalf("two NonceGens returned the same first nonce %x", a)
+	}
+}
+
+func TestNonceGenConcurrent(t *testing.T) {
+	const (
+		goroutines = 8
+		each       = 20000
+	)
+	g := NewNonceGen()
+	out := make([][][NonceSize]byte, goroutines)
+	var wg sync.WaitGroup
+	for i := range out {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < each; j++ {
+				out[i] = append(out[i], g.Next())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[[NonceSize]byte]struct{}, goroutines*each)
+	for _, ns := range out {
+		for _, n := range ns {
+			if _, ok := seen[n]; ok {
+				t.Fatalf("duplicate nonce %x", n)
+			}
+			seen[n] = struct{}{}
+		}
+	}
+}
+
+func TestNonceGenExhausted(t *testing.T) {
+	g := NewNonceGen()
+	g.ctr = math.MaxUint64 - 1
+	n := g.Next()
+	if !bytes.Equal(n[4:], []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}) {
+		t.Fatalf("unexpected counter %x", n[4:])
+	}
+	checkPanics(t, "Next", func() { g.Next() })
+	checkPanics(t, "Next", func() { g.Next() })
+}
@@ -0,0 +1,99 @@
+package saferand
+
+import (
+	"errors"
+)
+
+var (
+	errPasswordTooShort = errors.New("saferand: password length is too short for policy")
+	errInvalidPolicy    = errors.New("saferand: invalid password policy")
+)
+
+// DefaultSymbols is a set of symbols suitable for
+// PasswordPolicy.Symbols.
+const DefaultSymbols = "!#$%&()*+,-./:;<=>?@[]^_{|}~"
+
+const (
+	lowerChars = "abcdefghijklmnopqrstuvwxyz"
+	upperChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars = "0123456789"
+)
+
+// PasswordPolicy describes the characters in a password
+// generated by Password.
+type PasswordPolicy struct {
+	// MinLower is the minimum number of lowercase letters.
+	MinLower int
+	// MinUpper is the minimum number of uppercase letters.
+	MinUpper int
+	// MinDigits is the minimum number of digits.
+	MinDigits int
+	// MinSymbols is the minimum number of characters from
+	// Symbols.
+	MinSymbols int
+	// Symbols is the set of allowed symbols.
+	//
+	// If empty, the password does not contain symbols.
+	Symbols string
+}
+
+// Password returns a random password of length characters that
+// satisfies policy.
+//
+// It returns an error if policy cannot be satisfied by
+// a password of the given length.
+func Password(length int, policy PasswordPolicy) (string, error) {
+	return defaultRand.Password(length, policy)
+}
+
+// Password returns a random password of length characters that
+// satisfies policy.
+//
+// The password contains the minimum number of characters from
+// each class in the policy. The remaining characters are drawn
+// uniformly from all allowed characters. The characters are
+// then shuffled, so every arrangement is equally likely.
+//
+// It returns an error if policy cannot be satisfied by
+// a password of the given length.
+func (r *Rand) Password(length int, policy PasswordPolicy) (string, error) {
+	if length < 0 ||
+		policy.MinLower < 0 ||
+		policy.MinUpper < 0 ||
+		policy.MinDigits < 0 ||
+		policy.MinSymbols < 0 ||
+		(policy.MinSymbols > 0 && policy.Symbols == "") {
+		return "", errInvalidPolicy
+	}
+	required := policy.MinLower + policy.MinUpper +
+		policy.MinDigits + policy.MinSymbols
+	if required > length || required < 0 {
+		return "", errPasswordTooShort
+	}
+
+	symbols := []rune(policy.Symbols)
+	all := []rune(lowerChars + upperChars + digitChars)
+	all = append(all, symbols...)
+
+	pw := make([]rune, 0, length)
+	for _, class := range []struct {
+		runes []rune
+		n     int
+	}{
+		{[]rune(lowerChars), policy.MinLower},
+		{[]rune(upperChars), policy.MinUpper},
+		{[]rune(digitChars), policy.MinDigits},
+		{symbols, policy.MinSymbols},
+		{all, length - required},
+	} {
+		for i := 0; i < class.n; i++ {
+			pw = append(pw, class.runes[r.Uint32n(uint32(len(class.runes)))])
+		}
+	}
+	shuffleSlice(r, pw)
+	s := string(pw)
+	for i := range pw {
+		pw[i] = 0
+	}
+	return s, nil
+}
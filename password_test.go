@@ -0,0 +1,88 @@
+package saferand
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPassword(t *testing.T) {
+	for _, tc := range []struct {
+		length int
+		policy PasswordPolicy
+	}{
+		{0, PasswordPolicy{}},
+		{16, PasswordPolicy{}},
+		{4, PasswordPolicy{MinLower: 1, MinUpper: 1, MinDigits: 1, MinSymbols: 1, Symbols: DefaultSymbols}},
+		{12, PasswordPolicy{MinLower: 1, MinUpper: 1, MinDigits: 1, MinSymbols: 1, Symbols: DefaultSymbols}},
+		{20, PasswordPolicy{MinDigits: 10}},
+		{8, PasswordPolicy{MinSymbols: 3, Symbols: "±§"}},
+	} {
+		for i := 0; i < 1000; i++ {
+			pw, err := Password(tc.length, tc.policy)
+			if err != nil {
+				t.Fatalf("%d, %+v: %v", tc.length, tc.policy, err)
+			}
+			if n := utf8.RuneCountInString(pw); n != tc.length {
+				t.Fatalf("%d, %+v: got %d characters", tc.length, tc.policy, n)
+			}
+			var lower, upper, digits, symbols int
+			for _, c := range pw {
+				switch {
+				case strings.ContainsRune(lowerChars, c):
+					lower++
+				case strings.ContainsRune(upperChars, c):
+					upper++
+				case strings.ContainsRune(digitChars, c):
+					digits++
+				case strings.ContainsRune(tc.policy.Symbols, c):
+					symbols++
+				default:
+					t.Fatalf("%d, %+v: unexpected character %q", tc.length, tc.policy, c)
+				}
+			}
+			if lower < tc.policy.MinLower ||
+				upper < tc.policy.MinUpper ||
+				digits < tc.policy.MinDigits ||
+				symbols < tc.policy.MinSymbols {
+				t.Fatalf("%d, %+v: %q does not satisfy the policy", tc.length, tc.policy, pw)
+			}
+		}
+	}
+}
+
+// TestPasswordPositions checks that the required characters
+// are not always in the same positions.
+func TestPasswordPositions(t *testing.T) {
+	policy := PasswordPolicy{MinDigits: 1}
+	counts := make([]int, 8)
+	for i := 0; i < 10000; i++ {
+		pw, err := Password(len(counts), policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[strings.IndexAny(pw, digitChars)]++
+	}
+	for i, c := range counts {
+		if c == 0 {
+			t.Fatalf("a digit never appeared first at position %d", i)
+		}
+	}
+}
+
+func TestPasswordErrors(t *testing.T) {
+	for _, tc := range []struct {
+		length int
+		policy PasswordPolicy
+		err    error
+	}{
+		{-1, PasswordPolicy{}, errInvalidPolicy},
+		{3, PasswordPolicy{MinLower: 1, MinUpper: 1, MinDigits: 1, MinSymbols: 1, Symbols: "!"}, errPasswordTooShort},
+		{10, PasswordPolicy{MinLower: -1}, errInvalidPolicy},
+		{10, PasswordPolicy{MinSymbols: 1}, errInvalidPolicy},
+	} {
+		if _, err := Password(tc.length, tc.policy); err != tc.err {
+			t.Errorf("%d, %+v: expected %v, got %v", tc.length, tc.policy, tc.err, err)
+		}
+	}
+}
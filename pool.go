@@ -0,0 +1,36 @@
+package saferand
+
+import (
+	"sync"
+)
+
+var randPool = sync.Pool{
+	New: func() interface{} {
+		return New()
+	},
+}
+
+// Get returns a Rand from a pool of cryptographically secure
+// Rands.
+//
+// It is cheaper than New for short-lived Rands. The Rand should
+// be returned with Put once it is no longer needed and must not
+// be used afterward.
+func Get() *Rand {
+	return randPool.Get().(*Rand)
+}
+
+// Put returns r to the pool used by Get.
+//
+// Only Rands returned by Get or New are pooled. Rands that use
+// another Source are ignored so that they are never handed out
+// by Get.
+func Put(r *Rand) {
+	if r == nil {
+		return
+	}
+	if src, ok := r.src.(ExpSource); !ok || src.r != nil {
+		return
+	}
+	randPool.Put(r)
+}
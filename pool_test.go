@@ -0,0 +1,57 @@
+package saferand
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				r := Get()
+				r.NormFloat64()
+				r.Float64()
+				r.Intn(10)
+				Put(r)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPoolRejectsOtherSources(t *testing.T) {
+	Put(nil)
+	Put(NewWithSource(NewDeterministicSource(1)))
+	Put(NewWithSource(NewSourceFromReader(zeroReader{})))
+	for i := 0; i < 100; i++ {
+		r := Get()
+		if src, ok := r.src.(ExpSource); !ok || src.r != nil {
+			t.Fatalf("Get returned a Rand with Source %#v", r.src)
+		}
+	}
+}
+
+// benchRand prevents the compiler from optimizing away the
+// allocations in BenchmarkNewRand.
+var benchRand *Rand
+
+func BenchmarkGetPut(b *testing.B) {
+	b.ReportAllocs()
+	for n := b.N; n > 0; n-- {
+		r := Get()
+		r.Float64()
+		Put(r)
+	}
+}
+
+func BenchmarkNewRand(b *testing.B) {
+	b.ReportAllocs()
+	for n := b.N; n > 0; n-- {
+		benchRand = New()
+		benchRand.Float64()
+	}
+}
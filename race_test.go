@@ -0,0 +1,37 @@
+package saferand
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestConcurrentPackageFuncs hammers the package-level functions
+// from many goroutines. Run with -race.
+func TestConcurrentPackageFuncs(t *testing.T) {
+	const (
+		goroutines = 16
+		iters      = 1000
+	)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 3)
+			for j := 0; j < iters; j++ {
+				NormFloat64()
+				ExpFloat64()
+				Float64()
+				Float32()
+				Int()
+				Intn(1000)
+				Int63n(1000)
+				Uint64()
+				Read(buf)
+				Shuffle(5, func(i, j int) {})
+				Perm(5)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,179 @@
+package saferand
+
+import (
+	"errors"
+	"math"
+)
+
+var errExcludeAll = errors.New("saferand: exclusion set covers every value")
+
+// IntRange returns a uniform random number in [min, max).
+//
+// It panics if min >= max.
+func IntRange(min, max int) int { return defaultRand.IntRange(min, max) }
+
+// IntRange returns a uniform random number in [min, max).
+//
+// Unlike min + Intn(max-min), it does not overflow when
+// max-min exceeds math.MaxInt.
+//
+// It panics if min >= max.
+func (r *Rand) IntRange(min, max int) int {
+	if min >= max {
+		panic("invalid argument to IntRange")
+	}
+	// The difference always fits in a uint64, and the sum
+	// wraps around correctly.
+	span := uint64(max) - uint64(min)
+	return min + int(r.Uint64n(span))
+}
+
+// IntnInclusive returns a uniform random number in [0, n].
+//
+// It panics if n < 0.
+func IntnInclusive(n int) int { return defaultRand.IntnInclusive(n) }
+
+// IntnInclusive returns a uniform random number in [0, n].
+//
+// Unlike Intn(n+1), it does not overflow when n ==
+// math.MaxInt.
+//
+// It panics if n < 0.
+func (r *Rand) IntnInclusive(n int) int {
+	if n < 0 {
+		panic("invalid argument to IntnInclusive")
+	}
+	// n+1 is at most 2^63, so it fits in a uint64.
+	return int(r.Uint64n(uint64(n) + 1))
+}
+
+// IntnExcept returns a uniform random number in [0, n) that is
+// not in exclude.
+//
+// It returns an error if len(exclude) >= n.
+func IntnExcept(n int, exclude map[int]struct{}) (int, error) {
+	return defaultRand.IntnExcept(n, exclude)
+}
+
+// intnExceptAttempts is the number of rejection sampling
+// attempts IntnExcept makes before enumerating the allowed
+// values.
+const intnExceptAttempts = 32
+
+// IntnExcept returns a uniform random number in [0, n) that is
+// not in exclude.
+//
+// Values in exclude outside of [0, n) are ignored, but still
+// count toward len(exclude).
+//
+// It returns an error if len(exclude) >= n.
+func (r *Rand) IntnExcept(n int, exclude map[int]struct{}) (int, error) {
+	if len(exclude) >= n {
+		return 0, errExcludeAll
+	}
+	if len(exclude) == 0 {
+		return r.Intn(n), nil
+	}
+	for i := 0; i < intnExceptAttempts; i++ {
+		x := r.Intn(n)
+		if _, ok := exclude[x]; !ok {
+			return x, nil
+		}
+	}
+
+	// The exclusion set is dense, so pick the kth allowed
+	// value directly.
+	allowed := n
+	for x := range exclude {
+		if x >= 0 && x < n {
+			allowed--
+		}
+	}
+	k := r.Intn(allowed)
+	for x := 0; ; x++ {
+		if _, ok := exclude[x]; ok {
+			continue
+		}
+		if k == 0 {
+			return x, nil
+		}
+		k--
+	}
+}
+
+// Float64Range returns a uniform random number in [min, max).
+//
+// It returns min if min == max. It panics if min > max or
+// either is infinite or NaN.
+func Float64Range(min, max float64) float64 { return defaultRand.Float64Range(min, max) }
+
+// Float64Range returns a uniform random number in [min, max).
+//
+// The result never equals max, even after rounding, and spans
+// wider than math.MaxFloat64 do not overflow.
+//
+// It returns min if min == max. It panics if min > max or
+// either is infinite or NaN.
+func (r *Rand) Float64Range(min, max float64) float64 {
+	if !(min <= max) || math.IsInf(min, 0) || math.IsInf(max, 0) {
+		panic("invalid argument to Float64Range")
+	}
+	if min == max {
+		return min
+	}
+	f := r.Float64()
+	span := max - min
+	var x float64
+	if math.IsInf(span, 0) {
+		// Interpolate so that neither term overflows.
+		x = min*(1-f) + max*f
+	} else {
+		x = min + span*f
+	}
+	if x >= max {
+		return math.Nextafter(max, min)
+	}
+	if x < min {
+		return min
+	}
+	return x
+}
+
+// Float32Range returns a uniform random number in [min, max).
+//
+// It returns min if min == max. It panics if min > max or
+// either is infinite or NaN.
+func Float32Range(min, max float32) float32 { return defaultRand.Float32Range(min, max) }
+
+// Float32Range returns a uniform random number in [min, max).
+//
+// Like Float64Range, the result never equals max, even after
+// rounding, and spans wider than math.MaxFloat32 do not
+// overflow.
+//
+// It returns min if min == max. It panics if min > max or
+// either is infinite or NaN.
+func (r *Rand) Float32Range(min, max float32) float32 {
+	if !(min <= max) || math.IsInf(float64(min), 0) || math.IsInf(float64(max), 0) {
+		panic("invalid argument to Float32Range")
+	}
+	if min == max {
+		return min
+	}
+	f := r.Float32()
+	span := max - min
+	var x float32
+	if math.IsInf(float64(span), 0) {
+		// Interpolate so that neither term overflows.
+		x = min*(1-f) + max*f
+	} else {
+		x = min + span*f
+	}
+	if x >= max {
+		return math.Nextafter32(max, min)
+	}
+	if x < min {
+		return min
+	}
+	return x
+}
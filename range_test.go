@@ -0,0 +1,270 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntRange(t *testing.T) {
+	for _, tc := range []struct {
+		min, max int
+	}{
+		{0, 1},
+		{-10, 10},
+		{-20, -3},
+		{math.MinInt, math.MaxInt},
+		{math.MinInt, 0},
+		{-1, math.MaxInt},
+		{math.MaxInt - 5, math.MaxInt},
+	} {
+		for i := 0; i < 1000; i++ {
+			x := IntRange(tc.min, tc.max)
+			if x < tc.min || x >= tc.max {
+				t.Fatalf("IntRange(%d, %d): out of range: %d", tc.min, tc.max, x)
+			}
+		}
+	}
+
+	counts := make([]int, 17)
+	for i := 0; i < 100000; i++ {
+		counts[IntRange(-8, 9)+8]++
+	}
+	checkUniform(t, counts)
+
+	// A range wider than math.MaxInt should cover both halves
+	// evenly.
+	var halves [2]int
+	for i := 0; i < 100000; i++ {
+		if IntRange(math.MinInt, math.MaxInt) >= 0 {
+			halves[1]++
+		} else {
+			halves[0]++
+		}
+	}
+	checkUniform(t, halves[:])
+
+	checkPanics(t, "IntRange(0, 0)", func() { IntRange(0, 0) })
+	checkPanics(t, "IntRange(1, 0)", func() { IntRange(1, 0) })
+}
+
+func TestIntnInclusive(t *testing.T) {
+	counts := make([]int, 7)
+	for i := 0; i < 100000; i++ {
+		x := IntnInclusive(6)
+		if x < 0 || x > 6 {
+			t.Fatalf("IntnInclusive(6): out of range: %d", x)
+		}
+		counts[x]++
+	}
+	checkUniform(t, counts)
+
+	if x := IntnInclusive(0); x != 0 {
+		t.Fatalf("IntnInclusive(0): got %d", x)
+	}
+
+	// Both endpoints are reachable, including math.MaxInt,
+	// where n+1 would overflow.
+	for _, n := range []int{1, 6, math.MaxInt} {
+		if x := NewWithSource(fixedSource(0)).IntnInclusive(n); x != 0 {
+			t.Fatalf("IntnInclusive(%d) with zero source: got %d", n, x)
+		}
+	}
+	if x := NewWithSource(fixedSource(math.MaxUint64)).IntnInclusive(math.MaxInt); x != math.MaxInt {
+		t.Fatalf("IntnInclusive(math.MaxInt): got %d, expected %d", x, math.MaxInt)
+	}
+	var halves [2]int
+	for i := 0; i < 100000; i++ {
+		if IntnInclusive(math.MaxInt) > math.MaxInt/2 {
+			halves[1]++
+		} else {
+			halves[0]++
+		}
+	}
+	checkUniform(t, halves[:])
+
+	checkPanics(t, "IntnInclusive(-1)", func() { IntnInclusive(-1) })
+	checkPanics(t, "IntnInclusive(math.MinInt)", func() { IntnInclusive(math.MinInt) })
+}
+
+func TestIntnExcept(t *testing.T) {
+	// Sparse: rejection sampling almost always succeeds.
+	sparse := map[int]struct{}{3: {}, 7: {}, -1: {}, 100: {}}
+	counts := make([]int, 0, 8)
+	index := make(map[int]int)
+	for x := 0; x < 10; x++ {
+		if _, ok := sparse[x]; !ok {
+			index[x] = len(counts)
+			counts = append(counts, 0)
+		}
+	}
+	for i := 0; i < 100000; i++ {
+		x, err := IntnExcept(10, sparse)
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, ok := index[x]
+		if !ok {
+			t.Fatalf("IntnExcept: got excluded value %d", x)
+		}
+		counts[j]++
+	}
+	checkUniform(t, counts)
+
+	// Dense: most draws fall back to enumeration.
+	dense := make(map[int]struct{})
+	for x := 0; x < 1000; x++ {
+		if x%250 != 0 {
+			dense[x] = struct{}{}
+		}
+	}
+	var dcounts [4]int
+	for i := 0; i < 20000; i++ {
+		x, err := IntnExcept(1000, dense)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x%250 != 0 {
+			t.Fatalf("IntnExcept: got excluded value %d", x)
+		}
+		dcounts[x/250]++
+	}
+	checkUniform(t, dcounts[:])
+
+	// A source that always returns 0 exhausts the rejection
+	// attempts, and enumeration returns the first allowed
+	// value.
+	r := NewWithSource(fixedSource(0))
+	if x, err := r.IntnExcept(5, map[int]struct{}{0: {}, 1: {}}); err != nil || x != 2 {
+		t.Fatalf("IntnExcept with zero source: got (%d, %v), expected (2, nil)", x, err)
+	}
+
+	if x, err := IntnExcept(5, nil); err != nil || x < 0 || x >= 5 {
+		t.Fatalf("IntnExcept(5, nil): got (%d, %v)", x, err)
+	}
+	for _, tc := range []struct {
+		n       int
+		exclude map[int]struct{}
+	}{
+		{0, nil},
+		{-1, nil},
+		{2, map[int]struct{}{0: {}, 1: {}}},
+		{2, map[int]struct{}{0: {}, 5: {}}},
+	} {
+		if _, err := IntnExcept(tc.n, tc.exclude); err == nil {
+			t.Fatalf("IntnExcept(%d, %v): expected an error", tc.n, tc.exclude)
+		}
+	}
+}
+
+func TestFloat64Range(t *testing.T) {
+	for _, tc := range []struct {
+		min, max float64
+	}{
+		{0, 1},
+		{-1, 1},
+		{-100, -99.5},
+		{1, math.Nextafter(1, 2)},
+		{-math.MaxFloat64, math.MaxFloat64},
+		{0, math.MaxFloat64},
+		{1e300, 1e308},
+	} {
+		for i := 0; i < 10000; i++ {
+			x := Float64Range(tc.min, tc.max)
+			if !(x >= tc.min && x < tc.max) {
+				t.Fatalf("Float64Range(%g, %g): out of range: %g",
+					tc.min, tc.max, x)
+			}
+		}
+	}
+
+	// Rounding would otherwise produce max.
+	r := NewWithSource(fixedSource(math.MaxUint64))
+	if x := r.Float64Range(1, 1+0x1p-52); x != 1 {
+		t.Fatalf("expected 1, got %g", x)
+	}
+
+	if x := Float64Range(3, 3); x != 3 {
+		t.Fatalf("Float64Range(3, 3): got %g", x)
+	}
+
+	counts := make([]int, 20)
+	for i := 0; i < 100000; i++ {
+		counts[int(Float64Range(-5, 5)*2+10)]++
+	}
+	checkUniform(t, counts)
+
+	var halves [2]int
+	for i := 0; i < 100000; i++ {
+		if Float64Range(-math.MaxFloat64, math.MaxFloat64) >= 0 {
+			halves[1]++
+		} else {
+			halves[0]++
+		}
+	}
+	checkUniform(t, halves[:])
+
+	for _, tc := range [][2]float64{
+		{1, 0},
+		{math.NaN(), 1},
+		{0, math.NaN()},
+		{math.Inf(-1), 0},
+		{0, math.Inf(1)},
+	} {
+		checkPanics(t, "Float64Range", func() { Float64Range(tc[0], tc[1]) })
+	}
+}
+
+func TestFloat32Range(t *testing.T) {
+	for _, tc := range []struct {
+		min, max float32
+	}{
+		{0, 1},
+		{-1, 1},
+		{-100, -99.5},
+		{-3, -2},
+		{1, math.Nextafter32(1, 2)},
+		{-math.MaxFloat32, math.MaxFloat32},
+		{0, math.MaxFloat32},
+	} {
+		for i := 0; i < 10000; i++ {
+			x := Float32Range(tc.min, tc.max)
+			if !(x >= tc.min && x < tc.max) {
+				t.Fatalf("Float32Range(%g, %g): out of range: %g",
+					tc.min, tc.max, x)
+			}
+		}
+	}
+
+	// The largest Float32 is 1-2^-24, which rounds to max here.
+	r := NewWithSource(fixedSource(1<<53 - 1<<29))
+	if x := r.Float32Range(-2, -1); x != math.Nextafter32(-1, -2) {
+		t.Fatalf("expected %g, got %g", math.Nextafter32(-1, -2), x)
+	}
+
+	if x := Float32Range(3, 3); x != 3 {
+		t.Fatalf("Float32Range(3, 3): got %g", x)
+	}
+
+	counts := make([]int, 20)
+	for i := 0; i < 100000; i++ {
+		counts[int(Float32Range(-5, 5)*2+10)]++
+	}
+	checkUniform(t, counts)
+
+	neg := make([]int, 10)
+	for i := 0; i < 100000; i++ {
+		neg[int(Float32Range(-20, -10)+20)]++
+	}
+	checkUniform(t, neg)
+
+	for _, tc := range [][2]float32{
+		{1, 0},
+		{-1, -2},
+		{float32(math.NaN()), 1},
+		{0, float32(math.NaN())},
+		{float32(math.Inf(-1)), 0},
+		{0, float32(math.Inf(1))},
+	} {
+		checkPanics(t, "Float32Range", func() { Float32Range(tc[0], tc[1]) })
+	}
+}
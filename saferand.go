@@ -4,18 +4,84 @@
 //    import rand "github.com/ericlagergren/saferand"
 //
 // All Seed functions and methods are no-ops.
+//
+// Unlike exp/rand, Rand is a struct that embeds
+// *golang.org/x/exp/rand.Rand rather than an alias for it, so
+// a *saferand.Rand cannot be used where a *exprand.Rand is
+// expected. Pass the embedded field instead:
+//
+//    r := rand.New()
+//    f(r.Rand) // f takes a *exprand.Rand
+//
+// The package-level functions are safe for concurrent use by
+// multiple goroutines. Their shared Rand holds no per-call
+// state: its Source is stateless and Read bypasses the Rand
+// entirely.
 package saferand
 
 import (
 	"crypto/rand"
 	"encoding/binary"
-	"math"
+	"io"
+	"math/bits"
 
 	exprand "golang.org/x/exp/rand"
 )
 
-var defaultRand = exprand.New(NewSource())
+// DefaultSource is the Source used by the package-level
+// functions, such as Intn, Float64, and Shuffle.
+//
+// It is a cryptographically secure Source, so library code
+// that accepts a Source can be passed DefaultSource directly.
+//
+// Replacing DefaultSource changes the output of the
+// package-level functions. It is intended for tests and must
+// not be replaced while other goroutines use the package.
+// Read, ReadContext, ReadDeadline, Stream, and the Try
+// functions do not use DefaultSource. Use SetReader, which
+// affects all of them as well as DefaultSource's default
+// value.
+var DefaultSource Source = NewSource()
+
+// defaultRand uses whatever DefaultSource currently holds.
+var defaultRand = NewWithSource(defaultSource{})
+
+// defaultSource is a Source that forwards to DefaultSource.
+type defaultSource struct{}
+
+var (
+	_ exprand.Source = defaultSource{}
+	_ io.Reader      = defaultSource{}
+)
+
+func (defaultSource) Seed(_ uint64) {}
 
+func (defaultSource) Uint64() uint64 {
+	return DefaultSource.Uint64()
+}
+
+// Read reads from DefaultSource if it implements io.Reader.
+// Otherwise, it fills p using Uint64.
+func (defaultSource) Read(p []byte) (int, error) {
+	src := DefaultSource
+	if rd, ok := src.(io.Reader); ok {
+		return rd.Read(p)
+	}
+	n := len(p)
+	for len(p) >= 8 {
+		binary.LittleEndian.PutUint64(p, src.Uint64())
+		p = p[8:]
+	}
+	if len(p) > 0 {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], src.Uint64())
+		copy(p, b[:])
+		wipe(b[:])
+	}
+	return n, nil
+}
+
+func Bytes(n int) []byte                 { return defaultRand.Bytes(n) }
 func ExpFloat64() float64                { return defaultRand.ExpFloat64() }
 func Float32() float32                   { return defaultRand.Float32() }
 func Float64() float64                   { return defaultRand.Float64() }
@@ -27,26 +93,191 @@ func Int63n(n int64) int64               { return defaultRand.Int63n(n) }
 func Intn(n int) int                     { return defaultRand.Intn(n) }
 func NormFloat64() float64               { return defaultRand.NormFloat64() }
 func Perm(n int) []int                   { return defaultRand.Perm(n) }
-func Read(p []byte) (int, error)         { return rand.Read(p) }
+func Read(p []byte) (int, error)         { return Stream.Read(p) }
 func Seed(_ uint64)                      {}
 func Shuffle(n int, swap func(i, j int)) { defaultRand.Shuffle(n, swap) }
 func Uint32() uint32                     { return defaultRand.Uint32() }
 func Uint64() uint64                     { return defaultRand.Uint64() }
 
-type Rand = exprand.Rand
+// Uint32n returns a uniform random number in [0, n).
+//
+// It panics if n == 0.
+func Uint32n(n uint32) uint32 { return defaultRand.Uint32n(n) }
+
+// Uint64n returns a uniform random number in [0, n).
+//
+// It uses rejection sampling to avoid modulo bias. It panics if
+// n == 0.
+func Uint64n(n uint64) uint64 { return defaultRand.Uint64n(n) }
+
+// Int31nFast returns a uniform random number in [0, n).
+//
+// It is faster than Int31n, but returns different values for
+// the same Source. It panics if n <= 0.
+func Int31nFast(n int32) int32 { return defaultRand.Int31nFast(n) }
+
+// Int63nFast returns a uniform random number in [0, n).
+//
+// It is faster than Int63n, but returns different values for
+// the same Source. It panics if n <= 0.
+func Int63nFast(n int64) int64 { return defaultRand.Int63nFast(n) }
+
+// Rand is a source of random numbers.
+//
+// It has all of the methods of golang.org/x/exp/rand.Rand.
+// Use the embedded Rand field where a *exprand.Rand is
+// required.
+type Rand struct {
+	*exprand.Rand
+	src Source
+}
 
 // New returns a Rand that generated cryptographically secure
 // random values.
 func New() *Rand {
-	return exprand.New(NewSource())
+	return NewWithSource(NewSource())
+}
+
+// NewWithSource returns a Rand that uses random values from
+// src.
+//
+// The Rand is only as secure as src.
+func NewWithSource(src Source) *Rand {
+	return &Rand{
+		Rand: exprand.New(src),
+		src:  src,
+	}
+}
+
+// Read fills p with random bytes.
+//
+// If the Rand's Source implements io.Reader, Read reads directly
+// from the Source. Otherwise, it behaves like
+// golang.org/x/exp/rand.Rand.Read.
+func (r *Rand) Read(p []byte) (int, error) {
+	if rd, ok := r.src.(io.Reader); ok {
+		return rd.Read(p)
+	}
+	return r.Rand.Read(p)
+}
+
+// Bytes returns a slice of n random bytes.
+//
+// It panics if the underlying Source fails.
+func (r *Rand) Bytes(n int) []byte {
+	b := make([]byte, n)
+	r.fill(b)
+	return b
+}
+
+// BytesInto fills p with random bytes.
+//
+// Unlike Bytes, it does not allocate. It panics if the
+// underlying Source fails.
+func BytesInto(p []byte) { defaultRand.BytesInto(p) }
+
+// BytesInto fills p with random bytes.
+//
+// Unlike Bytes, it does not allocate. It panics if the
+// underlying Source fails.
+func (r *Rand) BytesInto(p []byte) {
+	r.fill(p)
+}
+
+// fill fills p with random bytes, panicking if the underlying
+// Source fails.
+func (r *Rand) fill(p []byte) {
+	if _, err := r.Read(p); err != nil {
+		panic(err)
+	}
+}
+
+// Uint32n returns a uniform random number in [0, n).
+//
+// It panics if n == 0.
+func (r *Rand) Uint32n(n uint32) uint32 {
+	if n == 0 {
+		panic("invalid argument to Uint32n")
+	}
+	// Lemire's multiply-shift reduction. The high 32 bits of
+	// x*n are in [0, n), but are biased when the low 32 bits
+	// fall below 2^32 mod n. Reject those.
+	//
+	// See https://arxiv.org/abs/1805.10941
+	m := uint64(r.Uint32()) * uint64(n)
+	if uint32(m) < n {
+		t := -n % n // 2^32 mod n
+		for uint32(m) < t {
+			m = uint64(r.Uint32()) * uint64(n)
+		}
+	}
+	return uint32(m >> 32)
+}
+
+// Int31nFast returns a uniform random number in [0, n).
+//
+// Unlike Int31n, which uses a division for every call, it uses
+// Lemire's nearly divisionless method (see Uint32n). It is
+// faster than Int31n, but returns different values for the
+// same Source.
+//
+// It panics if n <= 0.
+func (r *Rand) Int31nFast(n int32) int32 {
+	if n <= 0 {
+		panic("invalid argument to Int31nFast")
+	}
+	return int32(r.Uint32n(uint32(n)))
+}
+
+// Int63nFast returns a uniform random number in [0, n).
+//
+// Unlike Int63n, which uses a division for every call, it uses
+// Lemire's nearly divisionless method (see Uint32n). It is
+// faster than Int63n, but returns different values for the
+// same Source.
+//
+// It panics if n <= 0.
+func (r *Rand) Int63nFast(n int64) int64 {
+	if n <= 0 {
+		panic("invalid argument to Int63nFast")
+	}
+	return int64(r.uint64nFast(uint64(n)))
+}
+
+// uint64nFast is the 64-bit version of Uint32n.
+//
+// n must be non-zero.
+func (r *Rand) uint64nFast(n uint64) uint64 {
+	hi, lo := bits.Mul64(r.Uint64(), n)
+	if lo < n {
+		t := -n % n // 2^64 mod n
+		for lo < t {
+			hi, lo = bits.Mul64(r.Uint64(), n)
+		}
+	}
+	return hi
 }
 
 type Source = exprand.Source
 
-// ExpSource implements Source.
-type ExpSource struct{}
+// Reader is a global, shared instance of a cryptographically
+// secure random number generator.
+//
+// It is safe for concurrent use by multiple goroutines.
+var Reader io.Reader = ExpSource{}
 
-var _ exprand.Source = ExpSource{}
+// ExpSource implements Source and io.Reader.
+//
+// The zero value reads from crypto/rand, or from the reader
+// installed by SetReader.
+type ExpSource struct {
+	r io.Reader
+}
+
+var (
+	_ exprand.Source = ExpSource{}
+	_ io.Reader      = ExpSource{}
+)
 
 // NewSource returns a cryptographically secure Source.
 //
@@ -56,35 +287,143 @@ func NewSource() exprand.Source {
 	return ExpSource{}
 }
 
+// NewSourceFromReader returns a Source that reads from r instead
+// of crypto/rand.
+//
+// If r returns an error, the Source panics, just like the Source
+// returned by NewSource. The returned Source is only safe for
+// concurrent use if r is.
+func NewSourceFromReader(r io.Reader) exprand.Source {
+	return ExpSource{r: r}
+}
+
+// NewDeterministicSource returns a Source that produces the
+// same sequence of values for a given seed.
+//
+// The returned Source is NOT cryptographically secure and is
+// not safe for concurrent use by multiple goroutines. It is
+// intended for reproducible tests.
+func NewDeterministicSource(seed uint64) exprand.Source {
+	return exprand.NewSource(seed)
+}
+
+// TrySource is implemented by Sources that can report errors
+// instead of panicking.
+type TrySource interface {
+	// TryInt63 is like Int63, but returns an error instead of
+	// panicking.
+	TryInt63() (int64, error)
+	// TryUint64 is like Uint64, but returns an error instead of
+	// panicking.
+	TryUint64() (uint64, error)
+}
+
+var _ TrySource = ExpSource{}
+
+// TryInt63 is like Int63, but returns an error instead of
+// panicking if crypto/rand fails.
+func TryInt63() (int64, error) {
+	return ExpSource{}.TryInt63()
+}
+
+// TryUint64 is like Uint64, but returns an error instead of
+// panicking if crypto/rand fails.
+func TryUint64() (uint64, error) {
+	return ExpSource{}.TryUint64()
+}
+
+// reader returns the underlying reader, or nil for
+// crypto/rand.
+func (s ExpSource) reader() io.Reader {
+	if s.r != nil {
+		return s.r
+	}
+	return overrideReader()
+}
+
+// read returns eight bytes from the underlying reader.
+func (s ExpSource) read() ([8]byte, error) {
+	r := s.reader()
+	if r == nil {
+		var buf [8]byte
+		_, err := rand.Read(buf[:])
+		return buf, err
+	}
+	// Passing buf to an arbitrary io.Reader causes it to
+	// escape, so keep it separate from the common path.
+	var buf [8]byte
+	_, err := io.ReadFull(r, buf[:])
+	return buf, err
+}
+
 func (ExpSource) Seed(_ uint64) {}
 
-func (ExpSource) Int63() int64 {
-	buf := make([]byte, 8)
-	for i := 0; ; i++ {
-		if _, err := rand.Read(buf); err != nil {
-			panic(err)
-		}
-		const (
-			mask = 1<<7 - 1
-		)
-		buf[0] &= byte(mask)
-		x := binary.BigEndian.Uint64(buf)
-		if x < math.MaxInt64 {
-			return int64(x)
+// Read fills p with random bytes.
+//
+// It always returns len(p) and a nil error, or fewer than
+// len(p) bytes and a non-nil error.
+func (s ExpSource) Read(p []byte) (int, error) {
+	r := s.reader()
+	if r == nil {
+		return rand.Read(p)
+	}
+	return io.ReadFull(r, p)
+}
+
+// Int63 returns a non-negative random int64.
+//
+// If the underlying reader fails, it responds according to the
+// FailurePolicy set by SetFailurePolicy. By default, it panics.
+func (s ExpSource) Int63() int64 {
+	// Shifting out one bit maps each of the 2^63 values in
+	// [0, 2^63) from exactly two inputs, so the result is
+	// uniform without masking or rejection.
+	return int64(s.Uint64() >> 1)
+}
+
+// TryInt63 is like Int63, but returns an error instead of
+// following the FailurePolicy.
+func (s ExpSource) TryInt63() (int64, error) {
+	x, err := s.TryUint64()
+	return int64(x >> 1), err
+}
+
+// Uint64 returns a random uint64.
+//
+// If the underlying reader fails, it responds according to the
+// FailurePolicy set by SetFailurePolicy. By default, it panics.
+func (s ExpSource) Uint64() uint64 {
+	for {
+		x, err := s.tryUint64()
+		if err == nil || !fail(err) {
+			return x
 		}
 	}
 }
 
-func (ExpSource) Uint64() uint64 {
-	buf := make([]byte, 8)
-	if _, err := rand.Read(buf); err != nil {
-		panic(err)
+// TryUint64 is like Uint64, but returns an error instead of
+// following the FailurePolicy.
+//
+// It also returns any error recorded under PolicyError.
+func (s ExpSource) TryUint64() (uint64, error) {
+	if err := pendingFailure(); err != nil {
+		return 0, err
+	}
+	return s.tryUint64()
+}
+
+func (s ExpSource) tryUint64() (uint64, error) {
+	buf, err := s.read()
+	if err != nil {
+		return 0, err
 	}
-	return binary.LittleEndian.Uint64(buf)
+	x := binary.LittleEndian.Uint64(buf[:])
+	wipe(buf[:])
+	return x, nil
 }
 
 type Zipf = exprand.Zipf
 
 func NewZipf(r *Rand, s float64, v float64, imax uint64) *Zipf {
-	return exprand.NewZipf(r, s, v, imax)
+	return exprand.NewZipf(r.Rand, s, v, imax)
 }
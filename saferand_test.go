@@ -5,12 +5,19 @@
 package saferand
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"runtime"
 	"testing"
+	"testing/iotest"
+
+	exprand "golang.org/x/exp/rand"
 )
 
 const (
@@ -93,6 +100,46 @@ func checkSampleSliceDistributions(t *testing.T, samples []float64, nslices int,
 	}
 }
 
+// checkChiSquare fails the test if counts are unlikely to have
+// been drawn from the discrete distribution probs.
+//
+// The significance level is about 0.0001, so the test should
+// only fail spuriously about once in 10,000 runs.
+func checkChiSquare(t *testing.T, counts []int, probs []float64) {
+	t.Helper()
+	var total int
+	for _, c := range counts {
+		total += c
+	}
+	var x2 float64
+	df := -1
+	for i, c := range counts {
+		if probs[i] == 0 {
+			if c != 0 {
+				t.Errorf("#%d: got %d samples with probability zero", i, c)
+			}
+			continue
+		}
+		want := probs[i] * float64(total)
+		d := float64(c) - want
+		x2 += d * d / want
+		df++
+	}
+	if crit := chiSquareCritical(df); x2 > crit {
+		t.Errorf("chi-square %.2f exceeds critical value %.2f (counts: %v)", x2, crit, counts)
+	}
+}
+
+// checkUniform is checkChiSquare for a uniform distribution.
+func checkUniform(t *testing.T, counts []int) {
+	t.Helper()
+	probs := make([]float64, len(counts))
+	for i := range probs {
+		probs[i] = 1 / float64(len(counts))
+	}
+	checkChiSquare(t, counts, probs)
+}
+
 //
 // Normal distribution tests
 //
@@ -410,6 +457,266 @@ func TestReadEmpty(t *testing.T) {
 	}
 }
 
+func TestUint64n(t *testing.T) {
+	for _, n := range []uint64{1, 2, 3, 7, 10, 1<<63 + 1, math.MaxUint64} {
+		for i := 0; i < 1000; i++ {
+			if x := Uint64n(n); x >= n {
+				t.Fatalf("Uint64n(%d) = %d", n, x)
+			}
+		}
+	}
+	for _, n := range []uint64{3, 7, 10} {
+		counts := make([]int, n)
+		for i := 0; i < 100000; i++ {
+			counts[Uint64n(n)]++
+		}
+		checkUniform(t, counts)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for n == 0")
+		}
+	}()
+	Uint64n(0)
+}
+
+func TestUint32n(t *testing.T) {
+	for _, n := range []uint32{1, 2, 3, 7, 10, 1<<31 + 1, math.MaxUint32} {
+		for i := 0; i < 1000; i++ {
+			if x := Uint32n(n); x >= n {
+				t.Fatalf("Uint32n(%d) = %d", n, x)
+			}
+		}
+	}
+
+	counts := make([]int, 7)
+	for i := 0; i < 3e6; i++ {
+		counts[Uint32n(7)]++
+	}
+	checkUniform(t, counts)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for n == 0")
+		}
+	}()
+	Uint32n(0)
+}
+
+// TestUint32nReject tests that Uint32n rejects biased values.
+func TestUint32nReject(t *testing.T) {
+	// For n = 3, x = 0 is the only rejected value.
+	buf := []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0xff, 0xff, 0xff, 0xff,
+	}
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(buf)))
+	if got := r.Uint32n(3); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+}
+
+func TestIntnFast(t *testing.T) {
+	for _, n := range []int64{1, 3, 7, 10, 100, 1000} {
+		c31 := make([]int, n)
+		c63 := make([]int, n)
+		for i := 0; i < 100000; i++ {
+			c31[Int31nFast(int32(n))]++
+			c63[Int63nFast(n)]++
+		}
+		checkUniform(t, c31)
+		checkUniform(t, c63)
+	}
+
+	// A large, non-power-of-two bound. For n = 3/4 * 2^63,
+	// reducing a 63-bit value modulo n would make [0, 2^61)
+	// twice as likely as the rest of the range.
+	n := int64(3 << 61)
+	var quarters [3]int
+	for i := 0; i < 100000; i++ {
+		x := Int63nFast(n)
+		if x < 0 || x >= n {
+			t.Fatalf("Int63nFast(%d): out of range: %d", n, x)
+		}
+		quarters[x/(1<<61)]++
+	}
+	checkUniform(t, quarters[:])
+
+	for _, fn := range []func(){
+		func() { Int31nFast(0) },
+		func() { Int31nFast(-1) },
+		func() { Int63nFast(0) },
+		func() { Int63nFast(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// TestIntnWide tests that Intn handles bounds that do not fit
+// in an int32 without truncation or bias.
+func TestIntnWide(t *testing.T) {
+	// Intn reduces uint64(n) with Uint64n on every platform. For
+	// n = math.MaxInt, the largest value is rejected and the
+	// next is returned unchanged, so the result is n-1 even when
+	// int is 32 bits.
+	src := &seqSource{vals: []uint64{math.MaxUint64, math.MaxInt - 1}}
+	if got := NewWithSource(src).Intn(math.MaxInt); got != math.MaxInt-1 {
+		t.Fatalf("Intn(math.MaxInt): expected %d, got %d", math.MaxInt-1, got)
+	}
+
+	// The top half of [0, math.MaxInt) is as likely as the
+	// bottom half.
+	var halves [2]int
+	for i := 0; i < 100000; i++ {
+		x := Intn(math.MaxInt)
+		if x < 0 {
+			t.Fatalf("Intn(math.MaxInt): out of range: %d", x)
+		}
+		halves[x/(math.MaxInt/2+1)]++
+	}
+	checkUniform(t, halves[:])
+
+	if math.MaxInt == math.MaxInt32 {
+		t.Skip("int is 32 bits")
+	}
+	// For n = 3/4 * 2^62, truncating to 32 bits or reducing
+	// modulo n would skew the thirds.
+	wide := int64(3 << 60)
+	n := int(wide)
+	var thirds [3]int
+	var big bool
+	for i := 0; i < 100000; i++ {
+		x := Intn(n)
+		if x < 0 || x >= n {
+			t.Fatalf("Intn(%d): out of range: %d", n, x)
+		}
+		big = big || x > math.MaxInt32
+		thirds[int64(x)>>60]++
+	}
+	if !big {
+		t.Fatalf("Intn(%d) never exceeded math.MaxInt32", n)
+	}
+	checkUniform(t, thirds[:])
+}
+
+func TestInt63nFastReject(t *testing.T) {
+	// For n = 3, x = 0 is the only rejected value.
+	buf := make([]byte, 16)
+	for i := 8; i < 16; i++ {
+		buf[i] = 0xff
+	}
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(buf)))
+	if got := r.Int63nFast(3); got != 2 {
+		t.Fatalf("expected 2, got %d", got)
+	}
+}
+
+func BenchmarkInt63n(b *testing.B) {
+	for n := b.N; n > 0; n-- {
+		Int63n(1e9 + 7)
+	}
+}
+
+func BenchmarkInt63nFast(b *testing.B) {
+	for n := b.N; n > 0; n-- {
+		Int63nFast(1e9 + 7)
+	}
+}
+
+func BenchmarkInt63nDeterministic(b *testing.B) {
+	r := NewWithSource(NewDeterministicSource(1))
+	for n := b.N; n > 0; n-- {
+		r.Int63n(1e9 + 7)
+	}
+}
+
+func BenchmarkInt63nFastDeterministic(b *testing.B) {
+	r := NewWithSource(NewDeterministicSource(1))
+	for n := b.N; n > 0; n-- {
+		r.Int63nFast(1e9 + 7)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	if b := Bytes(0); b == nil || len(b) != 0 {
+		t.Fatalf("Bytes(0): expected empty, non-nil slice, got %#v", b)
+	}
+	for _, n := range []int{1, 16, 1000} {
+		b := Bytes(n)
+		if len(b) != n {
+			t.Fatalf("Bytes(%d): got %d bytes", n, len(b))
+		}
+	}
+	if bytes.Equal(Bytes(32), Bytes(32)) {
+		t.Fatal("two successive calls returned the same bytes")
+	}
+
+	// Rands with a non-Reader Source still work.
+	r := NewWithSource(exprand.NewSource(1))
+	if b := r.Bytes(9); len(b) != 9 {
+		t.Fatalf("Bytes(9): got %d bytes", len(b))
+	}
+
+	r = NewWithSource(NewSourceFromReader(bytes.NewReader(nil)))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic after reader was exhausted")
+		}
+	}()
+	r.Bytes(1)
+}
+
+func TestBytesInto(t *testing.T) {
+	BytesInto(nil)
+
+	// Every byte is overwritten. Make the chance of a false
+	// failure negligible by checking across many fills.
+	b := make([]byte, 64)
+	var touched [64]bool
+	for i := 0; i < 32; i++ {
+		for j := range b {
+			b[j] = 0
+		}
+		BytesInto(b)
+		for j, c := range b {
+			if c != 0 {
+				touched[j] = true
+			}
+		}
+	}
+	for j, ok := range touched {
+		if !ok {
+			t.Fatalf("byte %d was never overwritten", j)
+		}
+	}
+
+	want := []byte{1, 2, 3, 4, 5}
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(want)))
+	got := make([]byte, len(want))
+	r.BytesInto(got)
+	if !bytes.Equal(got, want) {
+		t.Fatalf("expected %x, got %x", want, got)
+	}
+	checkPanics(t, "BytesInto after reader was exhausted", func() { r.BytesInto(got) })
+}
+
+func TestBytesIntoAllocs(t *testing.T) {
+	skipIfReadAllocates(t)
+
+	b := make([]byte, 64)
+	if n := testing.AllocsPerRun(100, func() { BytesInto(b) }); n != 0 {
+		t.Fatalf("BytesInto: got %v allocs, expected 0", n)
+	}
+}
+
 func TestShuffleSmall(t *testing.T) {
 	// Check that Shuffle allows n=0 and n=1, but that swap is never called for them.
 	r := New()
@@ -418,6 +725,224 @@ func TestShuffleSmall(t *testing.T) {
 	}
 }
 
+// skipIfReadAllocates skips the test if crypto/rand.Read
+// allocates.
+//
+// Older versions of crypto/rand leak the buffer passed to
+// Read, which forces it to escape no matter what we do. It
+// also allocates when built with -race.
+func skipIfReadAllocates(t *testing.T) {
+	t.Helper()
+	if n := testing.AllocsPerRun(100, func() {
+		var buf [8]byte
+		rand.Read(buf[:])
+	}); n != 0 {
+		t.Skipf("crypto/rand.Read allocates (%v allocs)", n)
+	}
+}
+
+func TestSourceAllocs(t *testing.T) {
+	skipIfReadAllocates(t)
+
+	src := NewSource()
+	if n := testing.AllocsPerRun(100, func() { src.Uint64() }); n != 0 {
+		t.Errorf("Uint64: got %v allocs, expected 0", n)
+	}
+	if n := testing.AllocsPerRun(100, func() { src.(ExpSource).Int63() }); n != 0 {
+		t.Errorf("Int63: got %v allocs, expected 0", n)
+	}
+}
+
+func TestSourceFromReader(t *testing.T) {
+	buf := []byte{
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0xff, 0xee, 0xdd, 0xcc, 0xbb, 0xaa, 0x99, 0x88,
+	}
+	src := NewSourceFromReader(bytes.NewReader(buf))
+	for i, want := range []uint64{
+		0x0807060504030201,
+		0x8899aabbccddeeff,
+	} {
+		if got := src.Uint64(); got != want {
+			t.Fatalf("#%d: expected %#x, got %#x", i, want, got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic after reader was exhausted")
+		}
+	}()
+	src.Uint64()
+}
+
+// TestSourceByteOrder tests that Int63 and Uint64 decode the
+// same bytes the same way.
+func TestSourceByteOrder(t *testing.T) {
+	buf := []byte{
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x88,
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x88,
+	}
+	src := NewSourceFromReader(bytes.NewReader(buf)).(ExpSource)
+	if got, want := src.Uint64(), uint64(0x8807060504030201); got != want {
+		t.Fatalf("Uint64: expected %#x, got %#x", want, got)
+	}
+	// Int63 shifts out the low bit.
+	if got, want := src.Int63(), int64(0x8807060504030201>>1); got != want {
+		t.Fatalf("Int63: expected %#x, got %#x", want, got)
+	}
+}
+
+func TestSourceInt63Uniform(t *testing.T) {
+	src := ExpSource{}
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x := src.Int63()
+		if x < 0 {
+			t.Fatalf("#%d: Int63 returned negative value %d", i, x)
+		}
+		hi[x>>59]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+
+	// The extremes of [0, 2^63) are both reachable.
+	for _, tc := range []struct {
+		x    uint64
+		want int64
+	}{
+		{0, 0},
+		{1, 0},
+		{math.MaxUint64, math.MaxInt64},
+	} {
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, tc.x)
+		src := NewSourceFromReader(bytes.NewReader(buf)).(ExpSource)
+		if got := src.Int63(); got != tc.want {
+			t.Fatalf("%#x: expected %#x, got %#x", tc.x, tc.want, got)
+		}
+	}
+}
+
+func TestDeterministicSource(t *testing.T) {
+	for _, seed := range testSeeds {
+		a := NewDeterministicSource(seed)
+		b := NewDeterministicSource(seed)
+		for i := 0; i < 100; i++ {
+			if x, y := a.Uint64(), b.Uint64(); x != y {
+				t.Fatalf("seed %d, #%d: %#x != %#x", seed, i, x, y)
+			}
+		}
+
+		p1 := NewWithSource(NewDeterministicSource(seed)).Perm(10)
+		p2 := NewWithSource(NewDeterministicSource(seed)).Perm(10)
+		for i := range p1 {
+			if p1[i] != p2[i] {
+				t.Fatalf("seed %d: %v != %v", seed, p1, p2)
+			}
+		}
+	}
+
+	if NewDeterministicSource(1).Uint64() == NewDeterministicSource(2).Uint64() {
+		t.Fatal("different seeds produced the same value")
+	}
+}
+
+func TestDefaultSource(t *testing.T) {
+	if _, ok := DefaultSource.(ExpSource); !ok {
+		t.Fatalf("unexpected DefaultSource %T", DefaultSource)
+	}
+
+	prev := DefaultSource
+	defer func() { DefaultSource = prev }()
+
+	DefaultSource = fixedSource(0x0807060504030201)
+	if got := Uint64(); got != 0x0807060504030201 {
+		t.Fatalf("Uint64: got %#x", got)
+	}
+	if got := Intn(10); got != NewWithSource(fixedSource(0x0807060504030201)).Intn(10) {
+		t.Fatalf("Intn: got %d", got)
+	}
+	// fixedSource is not an io.Reader.
+	if got := Bytes(11); !bytes.Equal(got, []byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3}) {
+		t.Fatalf("Bytes: got %x", got)
+	}
+
+	want := []byte{9, 8, 7, 6, 5}
+	DefaultSource = NewSourceFromReader(bytes.NewReader(want))
+	if got := Bytes(len(want)); !bytes.Equal(got, want) {
+		t.Fatalf("Bytes: got %x, expected %x", got, want)
+	}
+
+	DefaultSource = prev
+	if Uint64() == Uint64() {
+		t.Fatal("restored DefaultSource returned the same value twice")
+	}
+}
+
+func TestTrySource(t *testing.T) {
+	src := NewSourceFromReader(iotest.ErrReader(io.ErrUnexpectedEOF)).(TrySource)
+	if _, err := src.TryUint64(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("TryUint64: expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+	if _, err := src.TryInt63(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("TryInt63: expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	// A short read is also an error.
+	src = NewSourceFromReader(bytes.NewReader(make([]byte, 4))).(TrySource)
+	if _, err := src.TryUint64(); err != io.ErrUnexpectedEOF {
+		t.Fatalf("TryUint64: expected %v, got %v", io.ErrUnexpectedEOF, err)
+	}
+
+	if _, err := TryUint64(); err != nil {
+		t.Fatalf("TryUint64: unexpected error: %v", err)
+	}
+	if x, err := TryInt63(); err != nil || x < 0 {
+		t.Fatalf("TryInt63: unexpected result: (%d, %v)", x, err)
+	}
+}
+
+func TestReader(t *testing.T) {
+	if n, err := Reader.Read(nil); n != 0 || err != nil {
+		t.Fatalf("Read(nil): got (%d, %v)", n, err)
+	}
+	buf := make([]byte, 1<<20)
+	if n, err := Reader.Read(buf); n != len(buf) || err != nil {
+		t.Fatalf("Read: got (%d, %v)", n, err)
+	}
+	var zero int
+	for _, c := range buf {
+		if c == 0 {
+			zero++
+		}
+	}
+	// Expect about 4096 zero bytes.
+	if zero > 8192 {
+		t.Fatalf("too many zero bytes: %d", zero)
+	}
+}
+
+func TestReaderFromReader(t *testing.T) {
+	want := make([]byte, 1000)
+	for i := range want {
+		want[i] = byte(i)
+	}
+	// OneByteReader forces many internal reads.
+	r := NewSourceFromReader(iotest.OneByteReader(bytes.NewReader(want))).(io.Reader)
+	got := make([]byte, len(want))
+	if n, err := r.Read(got); n != len(got) || err != nil {
+		t.Fatalf("Read: got (%d, %v)", n, err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("Read returned incorrect bytes")
+	}
+	if n, err := r.Read(got); err == nil {
+		t.Fatalf("expected an error after reader was exhausted, got (%d, %v)", n, err)
+	}
+}
+
 // Benchmarks
 
 func BenchmarkSource(b *testing.B) {
@@ -427,6 +952,32 @@ func BenchmarkSource(b *testing.B) {
 	}
 }
 
+// int63Reject is the previous ExpSource.Int63: it masks off
+// the sign bit and rejects math.MaxInt64.
+func int63Reject(s ExpSource) int64 {
+	for {
+		x := s.Uint64() &^ (1 << 63)
+		if x < math.MaxInt64 {
+			return int64(x)
+		}
+	}
+}
+
+func BenchmarkSourceInt63(b *testing.B) {
+	b.Run("shift", func(b *testing.B) {
+		src := ExpSource{}
+		for n := b.N; n > 0; n-- {
+			src.Int63()
+		}
+	})
+	b.Run("reject", func(b *testing.B) {
+		src := ExpSource{}
+		for n := b.N; n > 0; n-- {
+			int63Reject(src)
+		}
+	})
+}
+
 func BenchmarkInt63Threadsafe(b *testing.B) {
 	for n := b.N; n > 0; n-- {
 		Int63()
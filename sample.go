@@ -0,0 +1,262 @@
+package saferand
+
+import (
+	"math"
+	"sort"
+)
+
+// SampleIndices returns k distinct indices chosen uniformly at
+// random from [0, n), in random order.
+//
+// It panics if n < 0 or k is not in [0, n].
+func SampleIndices(n, k int) []int { return defaultRand.SampleIndices(n, k) }
+
+// SampleIndices returns k distinct indices chosen uniformly at
+// random from [0, n), in random order.
+//
+// When k is a large fraction of n it performs a partial
+// Fisher-Yates shuffle of [0, n). Otherwise, it uses Floyd's
+// algorithm, which uses O(k) time and memory regardless of n.
+//
+// It panics if n < 0 or k is not in [0, n].
+func (r *Rand) SampleIndices(n, k int) []int {
+	if n < 0 || k < 0 || k > n {
+		panic("invalid argument to SampleIndices")
+	}
+	if k > n/4 {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		r.ShufflePartial(n, k, func(i, j int) {
+			s[i], s[j] = s[j], s[i]
+		})
+		return s[:k:k]
+	}
+
+	s := r.floyd(n, k)
+	// Floyd's algorithm selects a uniform subset, but the
+	// order is biased: j is always appended after t.
+	r.Shuffle(len(s), func(i, j int) {
+		s[i], s[j] = s[j], s[i]
+	})
+	return s
+}
+
+// floyd returns a uniformly chosen k-subset of [0, n) using
+// Floyd's algorithm. See "Programming Pearls: A Sample of
+// Brilliance" (1987).
+//
+// The subset is uniform, but its order is not.
+func (r *Rand) floyd(n, k int) []int {
+	s := make([]int, 0, k)
+	seen := make(map[int]struct{}, k)
+	for j := n - k; j < n; j++ {
+		t := int(r.Uint64n(uint64(j + 1)))
+		if _, ok := seen[t]; ok {
+			t = j
+		}
+		seen[t] = struct{}{}
+		s = append(s, t)
+	}
+	return s
+}
+
+// Combination returns a uniformly chosen k-subset of [0, n) in
+// ascending order.
+//
+// It panics if n < 0 or k is not in [0, n].
+func Combination(n, k int) []int { return defaultRand.Combination(n, k) }
+
+// Combination returns a uniformly chosen k-subset of [0, n) in
+// ascending order.
+//
+// Each of the C(n, k) subsets is equally likely. It uses
+// Floyd's algorithm, which takes O(k) memory and O(k log k)
+// time to sort regardless of n.
+//
+// It panics if n < 0 or k is not in [0, n].
+func (r *Rand) Combination(n, k int) []int {
+	if n < 0 || k < 0 || k > n {
+		panic("invalid argument to Combination")
+	}
+	s := r.floyd(n, k)
+	sort.Ints(s)
+	return s
+}
+
+// KPermutation returns an ordered sample of k distinct indices
+// from [0, n).
+//
+// It panics if n < 0 or k is not in [0, n].
+func KPermutation(n, k int) []int { return defaultRand.KPermutation(n, k) }
+
+// KPermutation returns an ordered sample of k distinct indices
+// from [0, n).
+//
+// Each of the n!/(n-k)! possible sequences is equally likely.
+// It performs the first k steps of a Fisher-Yates shuffle of
+// [0, n). When k is a small fraction of n, it tracks the
+// swapped positions in a map instead of materializing [0, n),
+// so it uses O(k) time and memory regardless of n.
+//
+// It panics if n < 0 or k is not in [0, n].
+func (r *Rand) KPermutation(n, k int) []int {
+	if n < 0 || k < 0 || k > n {
+		panic("invalid argument to KPermutation")
+	}
+	if k > n/4 {
+		s := make([]int, n)
+		for i := range s {
+			s[i] = i
+		}
+		r.ShufflePartial(n, k, func(i, j int) {
+			s[i], s[j] = s[j], s[i]
+		})
+		return s[:k:k]
+	}
+
+	// swapped[i] is the value at position i, if it is not i.
+	swapped := make(map[int]int, k)
+	at := func(i int) int {
+		if v, ok := swapped[i]; ok {
+			return v
+		}
+		return i
+	}
+	s := make([]int, k)
+	for i := range s {
+		j := i + int(r.Uint64n(uint64(n-i)))
+		s[i] = at(j)
+		// Position i is never read again, so only j needs
+		// updating.
+		swapped[j] = at(i)
+	}
+	return s
+}
+
+// SampleWithReplacement returns k elements chosen uniformly and
+// independently from s, so the same element may be chosen more
+// than once.
+//
+// It is the counterpart to SampleIndices, which samples without
+// replacement, and is equivalent to ChoiceN.
+//
+// It panics if k < 0, or if s is empty and k > 0.
+func SampleWithReplacement[T any](s []T, k int) []T {
+	if k < 0 || (len(s) == 0 && k > 0) {
+		panic("invalid argument to SampleWithReplacement")
+	}
+	return ChoiceN(s, k)
+}
+
+// SampleOrdered returns k distinct elements of s chosen
+// uniformly at random, in the order they appear in s.
+//
+// It panics if k < 0 or k > len(s).
+func SampleOrdered[T any](s []T, k int) []T {
+	return sampleOrdered(defaultRand, s, k)
+}
+
+func sampleOrdered[T any](r *Rand, s []T, k int) []T {
+	if k < 0 || k > len(s) {
+		panic("invalid argument to SampleOrdered")
+	}
+	// Selection sampling (Knuth's Algorithm S): with need
+	// elements left to choose from the n-i remaining, take
+	// s[i] with probability need/(n-i). Every k-subset is
+	// equally likely, and the result is a subsequence of s.
+	out := make([]T, 0, k)
+	n := len(s)
+	for i := 0; i < n && len(out) < k; i++ {
+		need := uint64(k - len(out))
+		if r.Uint64n(uint64(n-i)) < need {
+			out = append(out, s[i])
+		}
+	}
+	return out
+}
+
+// Reservoir is a uniform random sample of fixed size over a
+// stream of unknown length.
+//
+// It implements Algorithm L from Li, "Reservoir-Sampling
+// Algorithms of Time Complexity O(n(1 + log(N/n)))" (1994),
+// which draws O(k(1 + log(n/k))) random numbers for a stream of
+// n items.
+//
+// A Reservoir is not safe for concurrent use by multiple
+// goroutines.
+type Reservoir[T any] struct {
+	r *Rand
+	s []T
+	k int
+	// n is the number of items offered so far.
+	n uint64
+	// next is the 1-based index of the next item that will
+	// be added to the sample.
+	next uint64
+	w    float64
+}
+
+// NewReservoir returns a Reservoir that samples k items.
+//
+// It panics if k < 0.
+func NewReservoir[T any](k int) *Reservoir[T] {
+	if k < 0 {
+		panic("invalid argument to NewReservoir")
+	}
+	return &Reservoir[T]{
+		r: defaultRand,
+		s: make([]T, 0, k),
+		k: k,
+	}
+}
+
+// Offer adds item to the stream.
+func (v *Reservoir[T]) Offer(item T) {
+	v.n++
+	if len(v.s) < v.k {
+		v.s = append(v.s, item)
+		if len(v.s) == v.k {
+			v.w = math.Exp(math.Log(v.u()) / float64(v.k))
+			v.skip()
+		}
+		return
+	}
+	if v.k == 0 || v.n != v.next {
+		return
+	}
+	v.s[v.r.Uint64n(uint64(v.k))] = item
+	v.w *= math.Exp(math.Log(v.u()) / float64(v.k))
+	v.skip()
+}
+
+// skip computes the index of the next item to add to the sample.
+func (v *Reservoir[T]) skip() {
+	d := math.Floor(math.Log(v.u())/math.Log1p(-v.w)) + 1
+	if d >= float64(math.MaxUint64-v.n) {
+		v.next = math.MaxUint64
+		return
+	}
+	v.next = v.n + uint64(d)
+}
+
+// u returns a uniform random number in (0, 1).
+func (v *Reservoir[T]) u() float64 {
+	x := v.r.Float64()
+	for x == 0 {
+		x = v.r.Float64()
+	}
+	return x
+}
+
+// Sample returns a copy of the current sample.
+//
+// It contains min(k, n) items, where n is the number of items
+// offered so far. The order of the items is unspecified.
+func (v *Reservoir[T]) Sample() []T {
+	s := make([]T, len(v.s))
+	copy(s, v.s)
+	return s
+}
@@ -0,0 +1,333 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSampleIndices(t *testing.T) {
+	for _, tc := range []struct {
+		n, k int
+	}{
+		{0, 0},
+		{1, 1},
+		{10, 0},
+		{10, 2},  // Floyd
+		{10, 9},  // Fisher-Yates
+		{100, 7}, // Floyd
+		{100, 60},
+		{math.MaxInt, 3}, // Floyd, n too large for Fisher-Yates
+	} {
+		s := SampleIndices(tc.n, tc.k)
+		if len(s) != tc.k {
+			t.Fatalf("(%d, %d): got %d indices", tc.n, tc.k, len(s))
+		}
+		seen := make(map[int]bool)
+		for _, v := range s {
+			if v < 0 || v >= tc.n {
+				t.Fatalf("(%d, %d): out of range: %d", tc.n, tc.k, v)
+			}
+			if seen[v] {
+				t.Fatalf("(%d, %d): duplicate index %d", tc.n, tc.k, v)
+			}
+			seen[v] = true
+		}
+	}
+}
+
+func TestSampleIndicesUniform(t *testing.T) {
+	for _, tc := range []struct {
+		n, k int
+	}{
+		{40, 3},  // Floyd
+		{40, 30}, // Fisher-Yates
+	} {
+		// Every index should be included with probability
+		// k/n, and appear in each position with probability
+		// 1/n.
+		incl := make([]int, tc.n)
+		first := make([]int, tc.n)
+		last := make([]int, tc.n)
+		for i := 0; i < 50000; i++ {
+			s := SampleIndices(tc.n, tc.k)
+			for _, v := range s {
+				incl[v]++
+			}
+			first[s[0]]++
+			last[s[len(s)-1]]++
+		}
+		checkUniform(t, incl)
+		checkUniform(t, first)
+		checkUniform(t, last)
+	}
+}
+
+func TestKPermutationPanics(t *testing.T) {
+	checkPanics(t, "KPermutation(-1, 0)", func() { KPermutation(-1, 0) })
+	checkPanics(t, "KPermutation(1, -1)", func() { KPermutation(1, -1) })
+	checkPanics(t, "KPermutation(1, 2)", func() { KPermutation(1, 2) })
+}
+
+func TestSampleIndicesPanics(t *testing.T) {
+	checkPanics(t, "SampleIndices(-1, 0)", func() { SampleIndices(-1, 0) })
+	checkPanics(t, "SampleIndices(1, -1)", func() { SampleIndices(1, -1) })
+	checkPanics(t, "SampleIndices(1, 2)", func() { SampleIndices(1, 2) })
+}
+
+func TestKPermutation(t *testing.T) {
+	for _, tc := range []struct {
+		n, k int
+	}{
+		{0, 0},
+		{1, 1},
+		{10, 0},
+		{10, 2},  // sparse
+		{10, 10}, // dense
+		{100, 7}, // sparse
+		{100, 60},
+		{math.MaxInt, 3}, // sparse, n too large to materialize
+	} {
+		s := KPermutation(tc.n, tc.k)
+		if len(s) != tc.k {
+			t.Fatalf("(%d, %d): got %d indices", tc.n, tc.k, len(s))
+		}
+		seen := make(map[int]bool)
+		for _, v := range s {
+			if v < 0 || v >= tc.n {
+				t.Fatalf("(%d, %d): out of range: %d", tc.n, tc.k, v)
+			}
+			if seen[v] {
+				t.Fatalf("(%d, %d): duplicate index %d", tc.n, tc.k, v)
+			}
+			seen[v] = true
+		}
+	}
+}
+
+func TestKPermutationUniform(t *testing.T) {
+	for _, tc := range []struct {
+		n, k int
+	}{
+		{5, 1}, // sparse
+		{8, 2}, // sparse
+		{5, 2}, // dense
+		{4, 4}, // dense
+	} {
+		// Every ordered sequence should be equally likely.
+		counts := make(map[[4]int]int)
+		for i := 0; i < 60000; i++ {
+			var key [4]int
+			copy(key[:], KPermutation(tc.n, tc.k))
+			counts[key]++
+		}
+		want := 1
+		for i := 0; i < tc.k; i++ {
+			want *= tc.n - i
+		}
+		if len(counts) != want {
+			t.Fatalf("(%d, %d): got %d sequences, expected %d",
+				tc.n, tc.k, len(counts), want)
+		}
+		c := make([]int, 0, len(counts))
+		for _, v := range counts {
+			c = append(c, v)
+		}
+		checkUniform(t, c)
+	}
+}
+
+func TestCombination(t *testing.T) {
+	for _, tc := range []struct {
+		n, k int
+	}{
+		{0, 0},
+		{1, 1},
+		{10, 0},
+		{10, 3},
+		{10, 10},
+		{math.MaxInt, 5},
+	} {
+		s := Combination(tc.n, tc.k)
+		if len(s) != tc.k {
+			t.Fatalf("(%d, %d): got %d indices", tc.n, tc.k, len(s))
+		}
+		for i, v := range s {
+			if v < 0 || v >= tc.n {
+				t.Fatalf("(%d, %d): out of range: %d", tc.n, tc.k, v)
+			}
+			if i > 0 && v <= s[i-1] {
+				t.Fatalf("(%d, %d): not strictly ascending: %v", tc.n, tc.k, s)
+			}
+		}
+	}
+
+	// Every 3-subset of [0, 6) should be equally likely.
+	counts := make(map[[3]int]int)
+	for i := 0; i < 40000; i++ {
+		var key [3]int
+		copy(key[:], Combination(6, 3))
+		counts[key]++
+	}
+	if len(counts) != 20 {
+		t.Fatalf("got %d subsets, expected 20", len(counts))
+	}
+	c := make([]int, 0, len(counts))
+	for _, v := range counts {
+		c = append(c, v)
+	}
+	checkUniform(t, c)
+
+	checkPanics(t, "Combination(-1, 0)", func() { Combination(-1, 0) })
+	checkPanics(t, "Combination(1, -1)", func() { Combination(1, -1) })
+	checkPanics(t, "Combination(1, 2)", func() { Combination(1, 2) })
+}
+
+func TestSampleWithReplacement(t *testing.T) {
+	s := []string{"a", "b", "c", "d", "e"}
+	index := map[string]int{"a": 0, "b": 1, "c": 2, "d": 3, "e": 4}
+
+	if got := SampleWithReplacement(s, 0); len(got) != 0 {
+		t.Fatalf("k = 0: got %v", got)
+	}
+	if got := SampleWithReplacement([]int(nil), 0); len(got) != 0 {
+		t.Fatalf("empty slice: got %v", got)
+	}
+
+	// Each position is uniform over s, and k > len(s) is
+	// allowed.
+	const k = 8
+	pos := make([][]int, k)
+	for i := range pos {
+		pos[i] = make([]int, len(s))
+	}
+	var dup bool
+	for i := 0; i < 20000; i++ {
+		got := SampleWithReplacement(s, k)
+		if len(got) != k {
+			t.Fatalf("got %d elements, expected %d", len(got), k)
+		}
+		seen := make(map[string]bool)
+		for j, v := range got {
+			dup = dup || seen[v]
+			seen[v] = true
+			pos[j][index[v]]++
+		}
+	}
+	if !dup {
+		t.Fatal("no element was ever chosen twice")
+	}
+	for _, counts := range pos {
+		checkUniform(t, counts)
+	}
+
+	checkPanics(t, "SampleWithReplacement(s, -1)", func() { SampleWithReplacement(s, -1) })
+	checkPanics(t, "SampleWithReplacement(nil, 1)", func() { SampleWithReplacement([]int(nil), 1) })
+}
+
+func TestSampleOrdered(t *testing.T) {
+	s := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	for _, k := range []int{0, 1, 3, 9, 10} {
+		got := SampleOrdered(s, k)
+		if len(got) != k {
+			t.Fatalf("k = %d: got %d elements", k, len(got))
+		}
+		for i := 1; i < len(got); i++ {
+			if got[i] <= got[i-1] {
+				t.Fatalf("k = %d: not a subsequence: %v", k, got)
+			}
+		}
+	}
+
+	// Every element is included with probability k/n, and
+	// every subset is equally likely.
+	incl := make([]int, len(s))
+	subsets := make(map[[3]int]int)
+	for i := 0; i < 60000; i++ {
+		got := SampleOrdered(s, 3)
+		for _, v := range got {
+			incl[v]++
+		}
+		var key [3]int
+		copy(key[:], got)
+		subsets[key]++
+	}
+	checkUniform(t, incl)
+	if len(subsets) != 120 {
+		t.Fatalf("got %d subsets, expected 120", len(subsets))
+	}
+	c := make([]int, 0, len(subsets))
+	for _, v := range subsets {
+		c = append(c, v)
+	}
+	checkUniform(t, c)
+
+	checkPanics(t, "SampleOrdered(s, -1)", func() { SampleOrdered(s, -1) })
+	checkPanics(t, "SampleOrdered(s, 11)", func() { SampleOrdered(s, 11) })
+}
+
+func TestReservoir(t *testing.T) {
+	for _, tc := range []struct {
+		k, n int
+	}{
+		{1, 10},
+		{5, 5},
+		{5, 50},
+		{3, 1000},
+	} {
+		counts := make([]int, tc.n)
+		for i := 0; i < 20000; i++ {
+			v := NewReservoir[int](tc.k)
+			for j := 0; j < tc.n; j++ {
+				v.Offer(j)
+			}
+			s := v.Sample()
+			if len(s) != tc.k {
+				t.Fatalf("(%d, %d): got %d items", tc.k, tc.n, len(s))
+			}
+			for _, x := range s {
+				counts[x]++
+			}
+		}
+		if tc.k == tc.n {
+			for j, c := range counts {
+				if c != 20000 {
+					t.Fatalf("(%d, %d): item %d sampled %d times", tc.k, tc.n, j, c)
+				}
+			}
+			continue
+		}
+		if tc.n > 100 {
+			// Pool into buckets so each has a reasonable
+			// expected count.
+			pooled := make([]int, 50)
+			for j, c := range counts {
+				pooled[j*len(pooled)/tc.n] += c
+			}
+			counts = pooled
+		}
+		checkUniform(t, counts)
+	}
+}
+
+func TestReservoirShortStream(t *testing.T) {
+	v := NewReservoir[string](10)
+	if s := v.Sample(); len(s) != 0 {
+		t.Fatalf("expected an empty sample, got %v", s)
+	}
+	v.Offer("a")
+	v.Offer("b")
+	s := v.Sample()
+	if len(s) != 2 || s[0] != "a" || s[1] != "b" {
+		t.Fatalf("expected [a b], got %v", s)
+	}
+
+	z := NewReservoir[int](0)
+	for i := 0; i < 100; i++ {
+		z.Offer(i)
+	}
+	if s := z.Sample(); len(s) != 0 {
+		t.Fatalf("expected an empty sample, got %v", s)
+	}
+
+	checkPanics(t, "NewReservoir(-1)", func() { NewReservoir[int](-1) })
+}
@@ -0,0 +1,125 @@
+package saferand
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestGammaSampler(t *testing.T) {
+	for _, tc := range []struct {
+		shape, scale float64
+	}{
+		{0.1, 1},
+		{0.5, 2},
+		{1, 1},
+		{2.5, 0.5},
+		{1000, 0.01},
+	} {
+		g, err := NewGammaSampler(tc.shape, tc.scale)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// With the same Source, the sampler matches Gamma.
+		a := NewWithSource(NewDeterministicSource(1))
+		b := NewWithSource(NewDeterministicSource(1))
+		for i := 0; i < 1000; i++ {
+			if x, y := g.Sample(a), b.Gamma(tc.shape, tc.scale); x != y {
+				t.Fatalf("(%g, %g) #%d: Sample = %g, Gamma = %g",
+					tc.shape, tc.scale, i, x, y)
+			}
+		}
+
+		r := New()
+		samples := make([]float64, 100000)
+		for i := range samples {
+			samples[i] = g.Sample(r)
+		}
+		mean := tc.shape * tc.scale
+		checkMoments(t, samples, mean, mean*tc.scale)
+	}
+
+	for _, tc := range [][2]float64{
+		{0, 1},
+		{1, 0},
+		{-1, 1},
+		{math.NaN(), 1},
+		{math.Inf(1), 1},
+		{1, math.Inf(1)},
+	} {
+		if _, err := NewGammaSampler(tc[0], tc[1]); err != errInvalidGamma {
+			t.Errorf("NewGammaSampler(%g, %g): expected %v, got %v",
+				tc[0], tc[1], errInvalidGamma, err)
+		}
+	}
+}
+
+func TestNormalSampler(t *testing.T) {
+	n, err := NewNormalSampler(3, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := NewWithSource(NewDeterministicSource(1))
+	b := NewWithSource(NewDeterministicSource(1))
+	for i := 0; i < 1000; i++ {
+		if x, y := n.Sample(a), b.Normal(3, 2); x != y {
+			t.Fatalf("#%d: Sample = %g, Normal = %g", i, x, y)
+		}
+	}
+
+	r := New()
+	samples := make([]float64, 100000)
+	for i := range samples {
+		samples[i] = n.Sample(r)
+	}
+	checkMoments(t, samples, 3, 4)
+
+	if _, err := NewNormalSampler(0, 0); err != nil {
+		t.Fatalf("stddev = 0: unexpected error: %v", err)
+	}
+	for _, tc := range [][2]float64{
+		{0, -1},
+		{0, math.NaN()},
+		{math.NaN(), 1},
+		{math.Inf(1), 1},
+		{0, math.Inf(1)},
+	} {
+		if _, err := NewNormalSampler(tc[0], tc[1]); err != errInvalidNormal {
+			t.Errorf("NewNormalSampler(%g, %g): expected %v, got %v",
+				tc[0], tc[1], errInvalidNormal, err)
+		}
+	}
+}
+
+func BenchmarkGammaSampler(b *testing.B) {
+	for _, shape := range []float64{0.5, 2.5} {
+		r := NewWithSource(NewDeterministicSource(1))
+		b.Run(fmt.Sprintf("shape=%g/func", shape), func(b *testing.B) {
+			for n := b.N; n > 0; n-- {
+				r.Gamma(shape, 1)
+			}
+		})
+		g, _ := NewGammaSampler(shape, 1)
+		b.Run(fmt.Sprintf("shape=%g/sampler", shape), func(b *testing.B) {
+			for n := b.N; n > 0; n-- {
+				g.Sample(r)
+			}
+		})
+	}
+}
+
+func BenchmarkNormalSampler(b *testing.B) {
+	r := NewWithSource(NewDeterministicSource(1))
+	b.Run("func", func(b *testing.B) {
+		for n := b.N; n > 0; n-- {
+			r.Normal(3, 2)
+		}
+	})
+	s, _ := NewNormalSampler(3, 2)
+	b.Run("sampler", func(b *testing.B) {
+		for n := b.N; n > 0; n-- {
+			s.Sample(r)
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package saferand
+
+import (
+	"bytes"
+	"errors"
+	"math/bits"
+)
+
+var (
+	errSelfTestZero   = errors.New("saferand: self-test: source returned all zero bytes")
+	errSelfTestRepeat = errors.New("saferand: self-test: source returned identical blocks")
+	errSelfTestBias   = errors.New("saferand: self-test: source output is biased")
+)
+
+const (
+	// selfTestBlockSize is the size of each block read by
+	// SelfTest.
+	selfTestBlockSize = 256
+
+	// selfTestMaxSkew is the largest allowed difference
+	// between the number of one bits and half of the bits
+	// read by SelfTest.
+	//
+	// The 4096 bits read have a standard deviation of 32 one
+	// bits, so this is 8 standard deviations: a healthy source
+	// fails with probability about 2^-50.
+	selfTestMaxSkew = 8 * 32
+)
+
+// SelfTest reads two blocks from the package's entropy source
+// and checks that the output is not obviously broken.
+//
+// It returns an error if the read fails, if either block is all
+// zeros, if the blocks are identical, or if the proportion of
+// one bits is far from one half. Passing the test does not
+// prove that the source is secure. It only catches gross
+// failures, such as a stuck or misconfigured source. Call it
+// at startup to fail fast. For continuous testing, see
+// NewHealthCheckedSource.
+//
+// SelfTest reads from crypto/rand, or from the reader installed
+// by SetReader.
+func SelfTest() error {
+	var buf [2 * selfTestBlockSize]byte
+	defer wipe(buf[:])
+	if _, err := Read(buf[:]); err != nil {
+		return err
+	}
+	a, b := buf[:selfTestBlockSize], buf[selfTestBlockSize:]
+
+	var zero [selfTestBlockSize]byte
+	if bytes.Equal(a, zero[:]) || bytes.Equal(b, zero[:]) {
+		return errSelfTestZero
+	}
+	if bytes.Equal(a, b) {
+		return errSelfTestRepeat
+	}
+	ones := 0
+	for _, c := range buf {
+		ones += bits.OnesCount8(c)
+	}
+	if skew := ones - len(buf)*8/2; skew > selfTestMaxSkew || -skew > selfTestMaxSkew {
+		return errSelfTestBias
+	}
+	return nil
+}
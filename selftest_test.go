@@ -0,0 +1,42 @@
+package saferand
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSelfTest(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		if err := SelfTest(); err != nil {
+			t.Fatalf("#%d: %v", i, err)
+		}
+	}
+}
+
+func TestSelfTestFailures(t *testing.T) {
+	block := make([]byte, selfTestBlockSize)
+	if _, err := Read(block); err != nil {
+		t.Fatal(err)
+	}
+	ones := bytes.Repeat([]byte{0xff}, selfTestBlockSize)
+	for _, tc := range []struct {
+		name string
+		r    io.Reader
+		err  error
+	}{
+		{"zero", zeroReader{}, errSelfTestZero},
+		{"zero second block", bytes.NewReader(append(block, make([]byte, selfTestBlockSize)...)), errSelfTestZero},
+		{"repeat", bytes.NewReader(append(block, block...)), errSelfTestRepeat},
+		{"biased", bytes.NewReader(append(ones, block...)), errSelfTestBias},
+		{"read error", iotest.ErrReader(io.ErrUnexpectedEOF), io.ErrUnexpectedEOF},
+	} {
+		restore := SetReader(tc.r)
+		err := SelfTest()
+		restore()
+		if err != tc.err {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
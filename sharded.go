@@ -0,0 +1,83 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+)
+
+// shardSize is the number of bytes each shard of a
+// ShardedSource reads from crypto/rand at once.
+const shardSize = 512
+
+// shard is a buffer of entropy owned by one goroutine at a
+// time.
+type shard struct {
+	// buf[off:] has not yet been handed out. buf[:off] has
+	// been handed out and zeroed.
+	buf [shardSize]byte
+	off int
+	// pid is the process ID when buf was last filled.
+	pid int
+}
+
+// ShardedSource is a Source that spreads buffered entropy
+// across a sync.Pool of shards.
+//
+// It is safe for concurrent use by multiple goroutines.
+type ShardedSource struct {
+	pool sync.Pool
+}
+
+var _ Source = (*ShardedSource)(nil)
+
+// NewShardedSource returns a ShardedSource that serves values
+// from per-P buffers.
+//
+// Each buffer holds 512 bytes read from crypto/rand at once.
+// The buffers are kept in a sync.Pool, so concurrent callers
+// usually draw from different buffers and do not contend on a
+// lock or on crypto/rand. Use it instead of NewSource or
+// NewBufferedSource when many goroutines generate values at
+// the same time.
+//
+// A buffer dropped by the garbage collector is not zeroed
+// first, but its bytes were never handed out. If the process
+// forks, the child discards a buffer's bytes before using it.
+func NewShardedSource() *ShardedSource {
+	s := &ShardedSource{}
+	s.pool.New = func() interface{} {
+		return &shard{off: shardSize}
+	}
+	return s
+}
+
+func (*ShardedSource) Seed(_ uint64) {}
+
+func (s *ShardedSource) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *ShardedSource) Uint64() uint64 {
+	sh := s.pool.Get().(*shard)
+	x := sh.uint64()
+	s.pool.Put(sh)
+	return x
+}
+
+// uint64 returns the next value from the shard, refilling it
+// as needed.
+func (sh *shard) uint64() uint64 {
+	if sh.off == len(sh.buf) || sh.pid != getpid() {
+		if _, err := rand.Read(sh.buf[:]); err != nil {
+			panic(err)
+		}
+		sh.off = 0
+		sh.pid = getpid()
+	}
+	b := sh.buf[sh.off : sh.off+8]
+	x := binary.LittleEndian.Uint64(b)
+	wipe(b)
+	sh.off += 8
+	return x
+}
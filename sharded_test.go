@@ -0,0 +1,89 @@
+package saferand
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestShardedSource(t *testing.T) {
+	src := NewShardedSource()
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x := src.Uint64()
+		hi[x>>60]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+
+	for i := 0; i < 1000; i++ {
+		if x := src.Int63(); x < 0 {
+			t.Fatalf("#%d: Int63 returned negative value %d", i, x)
+		}
+	}
+
+	// Handed out bytes are zeroed.
+	sh := &shard{off: shardSize}
+	for i := 0; i < shardSize/8+3; i++ {
+		sh.uint64()
+		for j, c := range sh.buf[:sh.off] {
+			if c != 0 {
+				t.Fatalf("#%d: byte %d not zeroed", i, j)
+			}
+		}
+	}
+	if sh.off != 3*8 {
+		t.Fatalf("expected shard to refill, off = %d", sh.off)
+	}
+}
+
+func TestShardedSourceConcurrent(t *testing.T) {
+	const (
+		goroutines = 16
+		each       = 5000
+	)
+	src := NewShardedSource()
+	out := make([][]uint64, goroutines)
+	var wg sync.WaitGroup
+	for i := range out {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < each; j++ {
+				out[i] = append(out[i], src.Uint64())
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Shards are never shared, so no value is handed out
+	// twice.
+	seen := make(map[uint64]bool, goroutines*each)
+	for _, xs := range out {
+		for _, x := range xs {
+			if seen[x] {
+				t.Fatalf("repeated value %#x", x)
+			}
+			seen[x] = true
+		}
+	}
+}
+
+func BenchmarkSourceParallel(b *testing.B) {
+	for _, tc := range []struct {
+		name string
+		src  Source
+	}{
+		{"ExpSource", NewSource()},
+		{"Buffered", NewBufferedSource(0)},
+		{"Sharded", NewShardedSource()},
+	} {
+		b.Run(tc.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					tc.src.Uint64()
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package saferand
+
+import (
+	"math"
+)
+
+// ShufflePartial performs the first k steps of a Fisher-Yates
+// shuffle over n elements.
+//
+// It panics if n < 0 or k is not in [0, n].
+func ShufflePartial(n, k int, swap func(i, j int)) { defaultRand.ShufflePartial(n, k, swap) }
+
+// ShufflePartial performs the first k steps of a Fisher-Yates
+// shuffle over n elements.
+//
+// Afterward, the first k positions hold a uniformly random
+// arrangement of k distinct elements chosen from all n. The
+// order of the remaining n-k positions is unspecified. It takes
+// O(k) time, calling swap(i, j) for each i in [0, k).
+//
+// It panics if n < 0 or k is not in [0, n].
+func (r *Rand) ShufflePartial(n, k int, swap func(i, j int)) {
+	if n < 0 || k < 0 || k > n {
+		panic("invalid argument to ShufflePartial")
+	}
+	for i := 0; i < k; i++ {
+		j := i + int(r.Uint64n(uint64(n-i)))
+		swap(i, j)
+	}
+}
+
+// ShuffleSlice shuffles s in place using the Fisher-Yates
+// algorithm.
+//
+// Every permutation of s is equally likely. It does not
+// allocate.
+func ShuffleSlice[T any](s []T) {
+	shuffleSlice(defaultRand, s)
+}
+
+// ShuffleSliceWith shuffles s in place using random values from
+// src.
+//
+// It is like ShuffleSlice, but takes an explicit Source. Given
+// deterministic Sources in the same state, it always produces
+// the same permutation, which is useful for golden tests.
+func ShuffleSliceWith[T any](src Source, s []T) {
+	shuffleSlice(NewWithSource(src), s)
+}
+
+// ShuffleWith is like Shuffle, but uses random values from src.
+//
+// It panics if n < 0.
+func ShuffleWith(src Source, n int, swap func(i, j int)) {
+	NewWithSource(src).Shuffle(n, swap)
+}
+
+// shuffleSlice shuffles s in place using random values from r.
+//
+// Go does not allow methods to have type parameters, so this
+// takes the Rand as an argument instead.
+func shuffleSlice[T any](r *Rand, s []T) {
+	for i := len(s) - 1; i > 0; i-- {
+		j := int(r.Uint64n(uint64(i + 1)))
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+// PermSecure returns a uniform random permutation of [0, n).
+//
+// It panics if n < 0.
+func PermSecure(n int) []int { return defaultRand.PermSecure(n) }
+
+// PermSecure returns a uniform random permutation of [0, n).
+//
+// Each Fisher-Yates step draws the swap index with Uint64n, so
+// indices past math.MaxInt32 are reached without bias. Use
+// PermInto to reuse an existing slice.
+//
+// It panics if n < 0.
+func (r *Rand) PermSecure(n int) []int {
+	if n < 0 {
+		panic("invalid argument to PermSecure")
+	}
+	dst := make([]int, n)
+	r.PermInto(dst)
+	return dst
+}
+
+// Perm32 returns a uniform random permutation of [0, n).
+//
+// It panics if n < 0.
+func Perm32(n int32) []int32 { return defaultRand.Perm32(n) }
+
+// Perm32 returns a uniform random permutation of [0, n).
+//
+// It is like PermSecure, but stores each element in 4 bytes
+// instead of 8 on 64-bit platforms, which halves the memory
+// needed for large permutations.
+//
+// It panics if n < 0.
+func (r *Rand) Perm32(n int32) []int32 {
+	if n < 0 {
+		panic("invalid argument to Perm32")
+	}
+	dst := make([]int32, n)
+	for i := range dst {
+		dst[i] = int32(i)
+	}
+	shuffleSlice(r, dst)
+	return dst
+}
+
+// PermPair returns a uniform random permutation of [0, n) and
+// its inverse.
+//
+// It panics if n < 0.
+func PermPair(n int) (perm, inv []int) { return defaultRand.PermPair(n) }
+
+// PermPair returns a uniform random permutation of [0, n) and
+// its inverse, so that inv[perm[i]] == i for every i.
+//
+// It panics if n < 0.
+func (r *Rand) PermPair(n int) (perm, inv []int) {
+	if n < 0 {
+		panic("invalid argument to PermPair")
+	}
+	perm = make([]int, n)
+	r.PermInto(perm)
+	inv = make([]int, n)
+	for i, v := range perm {
+		inv[v] = i
+	}
+	return perm, inv
+}
+
+// PermInto fills dst with a uniform random permutation of
+// [0, len(dst)).
+func PermInto(dst []int) { defaultRand.PermInto(dst) }
+
+// PermInto fills dst with a uniform random permutation of
+// [0, len(dst)).
+//
+// It is like Perm, but does not allocate.
+func (r *Rand) PermInto(dst []int) {
+	for i := range dst {
+		dst[i] = i
+	}
+	shuffleSlice(r, dst)
+}
+
+// ShuffleBytes shuffles b in place using the Fisher-Yates
+// algorithm.
+//
+// Every permutation of b is equally likely.
+func ShuffleBytes(b []byte) { defaultRand.ShuffleBytes(b) }
+
+// ShuffleBytes shuffles b in place using the Fisher-Yates
+// algorithm.
+//
+// Every permutation of b is equally likely. It does not
+// allocate and uses Lemire's method (see Uint32n) to choose
+// each index.
+func (r *Rand) ShuffleBytes(b []byte) {
+	for i := len(b) - 1; i > 0; i-- {
+		j := r.swapIndex(i)
+		b[i], b[j] = b[j], b[i]
+	}
+}
+
+// swapIndex returns a uniform random index in [0, i].
+//
+// It uses Uint32n when i+1 fits in a uint32 and uint64nFast
+// otherwise.
+func (r *Rand) swapIndex(i int) int {
+	if uint64(i) >= math.MaxUint32 {
+		return int(r.uint64nFast(uint64(i) + 1))
+	}
+	return int(r.Uint32n(uint32(i) + 1))
+}
@@ -0,0 +1,337 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+
+	exprand "golang.org/x/exp/rand"
+)
+
+func TestShufflePartial(t *testing.T) {
+	const (
+		n = 20
+		k = 5
+	)
+	// counts[i][v] is the number of times v landed in slot i.
+	var counts [k][n]int
+	for i := 0; i < 40000; i++ {
+		var s [n]int
+		for j := range s {
+			s[j] = j
+		}
+		ShufflePartial(n, k, func(i, j int) {
+			s[i], s[j] = s[j], s[i]
+		})
+		seen := make(map[int]bool)
+		for _, v := range s {
+			if seen[v] {
+				t.Fatalf("duplicate element %d: %v", v, s)
+			}
+			seen[v] = true
+		}
+		for j, v := range s[:k] {
+			counts[j][v]++
+		}
+	}
+	for i := range counts {
+		checkUniform(t, counts[i][:])
+	}
+}
+
+func TestShufflePartialEdges(t *testing.T) {
+	var calls int
+	swap := func(i, j int) { calls++ }
+
+	ShufflePartial(0, 0, swap)
+	ShufflePartial(10, 0, swap)
+	if calls != 0 {
+		t.Fatalf("expected no calls to swap, got %d", calls)
+	}
+	ShufflePartial(10, 10, swap)
+	if calls != 10 {
+		t.Fatalf("expected 10 calls to swap, got %d", calls)
+	}
+
+	checkPanics(t, "ShufflePartial(-1, 0)", func() { ShufflePartial(-1, 0, swap) })
+	checkPanics(t, "ShufflePartial(1, -1)", func() { ShufflePartial(1, -1, swap) })
+	checkPanics(t, "ShufflePartial(1, 2)", func() { ShufflePartial(1, 2, swap) })
+}
+
+func TestShuffleSlice(t *testing.T) {
+	// Every permutation of [0, 4) should be equally likely.
+	perms := make(map[[4]int]int)
+	for i := 0; i < 100000; i++ {
+		s := []int{0, 1, 2, 3}
+		ShuffleSlice(s)
+		perms[*(*[4]int)(s)]++
+	}
+	if len(perms) != 24 {
+		t.Fatalf("expected 24 permutations, got %d", len(perms))
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	checkUniform(t, counts)
+
+	// The elements should be preserved.
+	s := []string{"a", "b", "b", "c", "d", "d", "d"}
+	ShuffleSlice(s)
+	seen := make(map[string]int)
+	for _, v := range s {
+		seen[v]++
+	}
+	if seen["a"] != 1 || seen["b"] != 2 || seen["c"] != 1 || seen["d"] != 3 {
+		t.Fatalf("elements not preserved: %v", s)
+	}
+
+	ShuffleSlice([]int(nil))
+	ShuffleSlice([]int{1})
+}
+
+func TestShuffleSliceWith(t *testing.T) {
+	// Golden output for exp/rand's PCG source with seed 1.
+	want := []int{5, 4, 6, 3, 9, 2, 8, 0, 7, 1}
+
+	for i := 0; i < 3; i++ {
+		s := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+		ShuffleSliceWith(exprand.NewSource(1), s)
+		for j := range s {
+			if s[j] != want[j] {
+				t.Fatalf("#%d: got %v, expected %v", i, s, want)
+			}
+		}
+
+		s = []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+		ShuffleWith(exprand.NewSource(1), len(s), func(i, j int) {
+			s[i], s[j] = s[j], s[i]
+		})
+		for j := range s {
+			if s[j] != want[j] {
+				t.Fatalf("#%d: ShuffleWith: got %v, expected %v", i, s, want)
+			}
+		}
+	}
+
+	// A secure Source still shuffles uniformly.
+	perms := make(map[[3]int]int)
+	for i := 0; i < 60000; i++ {
+		s := []int{0, 1, 2}
+		ShuffleSliceWith(NewSource(), s)
+		perms[*(*[3]int)(s)]++
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	if len(counts) != 6 {
+		t.Fatalf("expected 6 permutations, got %d", len(counts))
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "ShuffleWith(src, -1, swap)", func() {
+		ShuffleWith(NewSource(), -1, func(i, j int) {})
+	})
+}
+
+func BenchmarkShuffleSlice(b *testing.B) {
+	s := make([]int, 1000)
+	b.ReportAllocs()
+	for n := b.N; n > 0; n-- {
+		ShuffleSlice(s)
+	}
+}
+
+func TestPermInto(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 10, 1000} {
+		dst := make([]int, n)
+		PermInto(dst)
+		seen := make([]bool, n)
+		for _, v := range dst {
+			if v < 0 || v >= n || seen[v] {
+				t.Fatalf("PermInto(%d): invalid permutation: %v", n, dst)
+			}
+			seen[v] = true
+		}
+	}
+
+	perms := make(map[[3]int]int)
+	dst := make([]int, 3)
+	for i := 0; i < 60000; i++ {
+		PermInto(dst)
+		perms[*(*[3]int)(dst)]++
+	}
+	if len(perms) != 6 {
+		t.Fatalf("expected 6 permutations, got %d", len(perms))
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	checkUniform(t, counts)
+
+	dst = make([]int, 100)
+	skipIfReadAllocates(t)
+	if n := testing.AllocsPerRun(100, func() { PermInto(dst) }); n != 0 {
+		t.Fatalf("expected zero allocations, got %.1f", n)
+	}
+}
+
+func TestPermSecure(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 1 << 18} {
+		p := PermSecure(n)
+		if len(p) != n {
+			t.Fatalf("PermSecure(%d): got %d elements", n, len(p))
+		}
+		seen := make([]bool, n)
+		for _, v := range p {
+			if v < 0 || v >= n || seen[v] {
+				t.Fatalf("PermSecure(%d): invalid permutation", n)
+			}
+			seen[v] = true
+		}
+	}
+
+	perms := make(map[[4]int]int)
+	for i := 0; i < 120000; i++ {
+		perms[*(*[4]int)(PermSecure(4))]++
+	}
+	if len(perms) != 24 {
+		t.Fatalf("expected 24 permutations, got %d", len(perms))
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "PermSecure(-1)", func() { PermSecure(-1) })
+}
+
+func TestPerm32(t *testing.T) {
+	for _, n := range []int32{0, 1, 2, 1000} {
+		p := Perm32(n)
+		if len(p) != int(n) {
+			t.Fatalf("Perm32(%d): got %d elements", n, len(p))
+		}
+		seen := make([]bool, n)
+		for _, v := range p {
+			if v < 0 || v >= n || seen[v] {
+				t.Fatalf("Perm32(%d): invalid permutation: %v", n, p)
+			}
+			seen[v] = true
+		}
+	}
+
+	perms := make(map[[3]int32]int)
+	for i := 0; i < 60000; i++ {
+		perms[*(*[3]int32)(Perm32(3))]++
+	}
+	if len(perms) != 6 {
+		t.Fatalf("expected 6 permutations, got %d", len(perms))
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "Perm32(-1)", func() { Perm32(-1) })
+}
+
+func TestPermPair(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 1000} {
+		perm, inv := PermPair(n)
+		if len(perm) != n || len(inv) != n {
+			t.Fatalf("PermPair(%d): got %d and %d elements", n, len(perm), len(inv))
+		}
+		seen := make([]bool, n)
+		for i, v := range perm {
+			if v < 0 || v >= n || seen[v] {
+				t.Fatalf("PermPair(%d): invalid permutation", n)
+			}
+			seen[v] = true
+			if inv[v] != i {
+				t.Fatalf("PermPair(%d): inv[perm[%d]] = %d", n, i, inv[v])
+			}
+		}
+		for i, v := range inv {
+			if perm[v] != i {
+				t.Fatalf("PermPair(%d): perm[inv[%d]] = %d", n, i, perm[v])
+			}
+		}
+	}
+
+	perms := make(map[[3]int]int)
+	for i := 0; i < 60000; i++ {
+		perm, _ := PermPair(3)
+		perms[*(*[3]int)(perm)]++
+	}
+	if len(perms) != 6 {
+		t.Fatalf("expected 6 permutations, got %d", len(perms))
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "PermPair(-1)", func() { PermPair(-1) })
+}
+
+func TestSwapIndex(t *testing.T) {
+	if math.MaxInt == math.MaxInt32 {
+		t.Skip("int cannot hold 2^32")
+	}
+	// ShuffleBytes cannot reach these indices without
+	// allocating more than 4 GiB, so test swapIndex directly.
+	// With a Source that always returns math.MaxUint64, both
+	// Uint32n and uint64nFast return their largest value, i.
+	big := uint64(1) << 32
+	for _, i := range []uint64{big - 2, big - 1, big, big + 1} {
+		r := NewWithSource(&seqSource{vals: []uint64{math.MaxUint64}})
+		if j := r.swapIndex(int(i)); j != int(i) {
+			t.Fatalf("swapIndex(%d): got %d, expected %d", i, j, i)
+		}
+	}
+}
+
+func TestShuffleBytes(t *testing.T) {
+	perms := make(map[string]int)
+	for i := 0; i < 100000; i++ {
+		b := []byte("abcd")
+		ShuffleBytes(b)
+		perms[string(b)]++
+	}
+	if len(perms) != 24 {
+		t.Fatalf("expected 24 permutations, got %d", len(perms))
+	}
+	counts := make([]int, 0, len(perms))
+	for _, c := range perms {
+		counts = append(counts, c)
+	}
+	checkUniform(t, counts)
+
+	// The multiset of bytes should be preserved.
+	b := make([]byte, 1000)
+	var want [256]int
+	for i := range b {
+		b[i] = byte(i * 7 % 251)
+		want[b[i]]++
+	}
+	ShuffleBytes(b)
+	var got [256]int
+	for _, c := range b {
+		got[c]++
+	}
+	if got != want {
+		t.Fatal("bytes not preserved")
+	}
+
+	ShuffleBytes(nil)
+	ShuffleBytes([]byte{1})
+	skipIfReadAllocates(t)
+	if n := testing.AllocsPerRun(100, func() { ShuffleBytes(b) }); n != 0 {
+		t.Fatalf("expected zero allocations, got %.1f", n)
+	}
+}
@@ -0,0 +1,80 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"io"
+	"math"
+)
+
+// Stream is a shared, cryptographically secure stream of
+// random bytes.
+//
+// Read, ReadByte, and the package-level functions that write
+// random bytes, like WriteTo and CopyN, read from Stream.
+var Stream RandReader
+
+// RandReader is an infinite stream of cryptographically secure
+// random bytes.
+//
+// All RandReaders share one buffered reader, so small reads
+// and ReadByte only read from crypto/rand when the buffer is
+// empty. Bytes are zeroed once they have been handed out, and
+// the buffer is discarded if the process forks. While a reader
+// is installed by SetReader, RandReader reads from it directly
+// and bypasses the buffer.
+//
+// A RandReader is safe for concurrent use by multiple
+// goroutines.
+type RandReader struct{}
+
+var (
+	_ io.Reader     = RandReader{}
+	_ io.ByteReader = RandReader{}
+	_ io.WriterTo   = RandReader{}
+)
+
+// streamReader buffers the output of crypto/rand for Stream.
+var streamReader = newBufferedReader(rand.Reader, 0)
+
+// Read fills p with random bytes.
+//
+// It always returns len(p) and a nil error, or fewer than
+// len(p) bytes and a non-nil error.
+func (RandReader) Read(p []byte) (int, error) {
+	if overrideReader() != nil {
+		return ExpSource{}.Read(p)
+	}
+	return streamReader.Read(p)
+}
+
+// ReadByte returns a single random byte.
+//
+// It never returns io.EOF.
+func (RandReader) ReadByte() (byte, error) {
+	if overrideReader() != nil {
+		var b [1]byte
+		_, err := ExpSource{}.Read(b[:])
+		return b[0], err
+	}
+	return streamReader.ReadByte()
+}
+
+// WriteTo writes random bytes to w until w returns an error
+// or math.MaxInt64 bytes have been written.
+//
+// It returns the number of bytes written and the first error
+// encountered, if any. It returns io.ErrShortWrite if w writes
+// fewer bytes than requested without returning an error. Use
+// the package-level WriteTo or CopyN to write a fixed number
+// of bytes.
+func (RandReader) WriteTo(w io.Writer) (int64, error) {
+	buf := copyBufPool.Get().(*[writeToBufferSize]byte)
+	defer func() {
+		wipe(buf[:])
+		copyBufPool.Put(buf)
+	}()
+	return copyRandom(w, math.MaxInt64, buf[:])
+}
+
+// ReadByte returns a single random byte from Stream.
+func ReadByte() (byte, error) { return Stream.ReadByte() }
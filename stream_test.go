@@ -0,0 +1,131 @@
+package saferand
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestStreamInterfaces(t *testing.T) {
+	var x interface{} = Stream
+	if _, ok := x.(io.Reader); !ok {
+		t.Fatal("Stream does not implement io.Reader")
+	}
+	if _, ok := x.(io.ByteReader); !ok {
+		t.Fatal("Stream does not implement io.ByteReader")
+	}
+	if _, ok := x.(io.WriterTo); !ok {
+		t.Fatal("Stream does not implement io.WriterTo")
+	}
+}
+
+func TestStreamReadByte(t *testing.T) {
+	// Interleave Read and ReadByte so that both straddle
+	// buffer refills.
+	var counts [16]int
+	p := make([]byte, 13)
+	for i := 0; i < 20000; i++ {
+		c, err := Stream.ReadByte()
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[c>>4]++
+		if i%5 == 0 {
+			if n, err := Stream.Read(p); n != len(p) || err != nil {
+				t.Fatalf("#%d: Read returned (%d, %v)", i, n, err)
+			}
+			for _, c := range p {
+				counts[c&15]++
+			}
+		}
+		if i%7 == 0 {
+			c, err := ReadByte()
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts[c>>4]++
+		}
+	}
+	checkUniform(t, counts[:])
+}
+
+func TestStreamWriteTo(t *testing.T) {
+	errWrite := errors.New("write failed")
+	const limit = 10*writeToBufferSize + 123
+	w := &limitWriter{n: limit, err: errWrite}
+	n, err := Stream.WriteTo(w)
+	if err != errWrite {
+		t.Fatalf("expected %v, got %v", errWrite, err)
+	}
+	if n != limit || w.buf.Len() != limit {
+		t.Fatalf("expected %d bytes, got %d (%d buffered)", limit, n, w.buf.Len())
+	}
+	var counts [256]int
+	for _, c := range w.buf.Bytes() {
+		counts[c]++
+	}
+	checkUniform(t, counts[:])
+
+	// io.Copy uses WriteTo.
+	w = &limitWriter{n: 100, err: errWrite}
+	if n, err := io.Copy(w, Stream); n != 100 || err != errWrite {
+		t.Fatalf("io.Copy returned (%d, %v)", n, err)
+	}
+}
+
+func TestStreamSetReader(t *testing.T) {
+	// Fill the buffer so that stale bytes would be visible.
+	Stream.ReadByte()
+
+	buf := []byte{1, 2, 3, 4, 5, 6}
+	defer SetReader(bytes.NewReader(buf))()
+	if c, err := Stream.ReadByte(); c != 1 || err != nil {
+		t.Fatalf("ReadByte: got (%d, %v)", c, err)
+	}
+	p := make([]byte, 3)
+	if _, err := Stream.Read(p); err != nil || !bytes.Equal(p, buf[1:4]) {
+		t.Fatalf("Read: got (%x, %v)", p, err)
+	}
+	if _, err := Read(p[:2]); err != nil || !bytes.Equal(p[:2], buf[4:6]) {
+		t.Fatalf("package Read: got (%x, %v)", p[:2], err)
+	}
+	if _, err := Stream.ReadByte(); err != io.EOF {
+		t.Fatalf("expected %v, got %v", io.EOF, err)
+	}
+}
+
+func TestStreamConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p := make([]byte, 3+i)
+			for j := 0; j < 1000; j++ {
+				var err error
+				if j%2 == 0 {
+					_, err = Stream.ReadByte()
+				} else {
+					_, err = Stream.Read(p)
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			w := &limitWriter{n: 5000, err: io.ErrClosedPipe}
+			if n, err := Stream.WriteTo(w); n != 5000 || err != io.ErrClosedPipe {
+				t.Errorf("WriteTo returned (%d, %v)", n, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func BenchmarkStreamReadByte(b *testing.B) {
+	for n := b.N; n > 0; n-- {
+		Stream.ReadByte()
+	}
+}
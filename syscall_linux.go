@@ -0,0 +1,61 @@
+package saferand
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync/atomic"
+
+	"golang.org/x/sys/unix"
+)
+
+// getrandom is unix.Getrandom, replaceable for testing.
+var getrandom = unix.Getrandom
+
+// noGetrandom is set to 1 once getrandom(2) is known to be
+// unavailable.
+var noGetrandom uint32
+
+// NewSyscallSource returns a cryptographically secure Source
+// that reads from the getrandom(2) system call.
+//
+// Unlike crypto/rand, it never falls back to reading
+// /dev/urandom, so it does not consume a file descriptor. If
+// the kernel does not support getrandom(2), it reads from
+// crypto/rand instead.
+//
+// Like NewSource, the returned Source is safe for concurrent
+// use by multiple goroutines.
+func NewSyscallSource() Source {
+	return ExpSource{r: getrandomReader{}}
+}
+
+// getrandomReader is an io.Reader that reads from getrandom(2).
+type getrandomReader struct{}
+
+func (getrandomReader) Read(p []byte) (int, error) {
+	if atomic.LoadUint32(&noGetrandom) != 0 {
+		return rand.Read(p)
+	}
+	flags := unix.GRND_NONBLOCK
+	for n := 0; n < len(p); {
+		m, err := getrandom(p[n:], flags)
+		switch {
+		case err == nil:
+			n += m
+		case errors.Is(err, unix.EINTR):
+			// Interrupted by a signal. Try again.
+		case errors.Is(err, unix.EAGAIN):
+			// The entropy pool has not been initialized
+			// yet. Try again, this time waiting for it.
+			flags = 0
+		case errors.Is(err, unix.ENOSYS):
+			// getrandom(2) was added in Linux 3.17.
+			atomic.StoreUint32(&noGetrandom, 1)
+			m, err := rand.Read(p[n:])
+			return n + m, err
+		default:
+			return n, err
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,111 @@
+package saferand
+
+import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// withGetrandom replaces getrandom with fn for the duration of
+// the test.
+func withGetrandom(t *testing.T, fn func([]byte, int) (int, error)) {
+	t.Helper()
+	orig := getrandom
+	getrandom = fn
+	t.Cleanup(func() {
+		getrandom = orig
+		atomic.StoreUint32(&noGetrandom, 0)
+	})
+}
+
+func TestSyscallSource(t *testing.T) {
+	src := NewSyscallSource()
+	seen := make(map[uint64]bool)
+	var hi, lo [16]int
+	for i := 0; i < 100000; i++ {
+		x := src.Uint64()
+		if seen[x] {
+			t.Fatalf("#%d: repeated value %#x", i, x)
+		}
+		seen[x] = true
+		hi[x>>60]++
+		lo[x&15]++
+	}
+	checkUniform(t, hi[:])
+	checkUniform(t, lo[:])
+
+	r := NewWithSource(src)
+	if bytes.Equal(r.Bytes(32), r.Bytes(32)) {
+		t.Fatal("two successive reads returned the same bytes")
+	}
+	// Larger than the 33554431 byte maximum of a single
+	// getrandom(2) call.
+	if b := r.Bytes(1 << 25); len(b) != 1<<25 {
+		t.Fatalf("got %d bytes", len(b))
+	}
+}
+
+func TestSyscallSourceRetry(t *testing.T) {
+	var calls, blocking int
+	withGetrandom(t, func(p []byte, flags int) (int, error) {
+		calls++
+		switch calls {
+		case 1:
+			return 0, unix.EINTR
+		case 2:
+			return 0, unix.EAGAIN
+		}
+		if flags&unix.GRND_NONBLOCK == 0 {
+			blocking++
+		}
+		// Short reads are retried.
+		if len(p) > 3 {
+			p = p[:3]
+		}
+		for i := range p {
+			p[i] = 0xaa
+		}
+		return len(p), nil
+	})
+	x, err := NewSyscallSource().(TrySource).TryUint64()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x != 0xaaaaaaaaaaaaaaaa {
+		t.Fatalf("got %#x", x)
+	}
+	if calls != 5 {
+		t.Fatalf("expected 5 calls, got %d", calls)
+	}
+	if blocking != 3 {
+		t.Fatalf("expected 3 blocking calls after EAGAIN, got %d", blocking)
+	}
+}
+
+func TestSyscallSourceFallback(t *testing.T) {
+	var calls int
+	withGetrandom(t, func(p []byte, flags int) (int, error) {
+		calls++
+		return 0, unix.ENOSYS
+	})
+	src := NewSyscallSource()
+	src.Uint64()
+	src.Uint64()
+	if calls != 1 {
+		t.Fatalf("expected 1 call, got %d", calls)
+	}
+}
+
+func TestSyscallSourceError(t *testing.T) {
+	want := unix.EFAULT
+	withGetrandom(t, func(p []byte, flags int) (int, error) {
+		return 0, want
+	})
+	_, err := NewSyscallSource().(TrySource).TryUint64()
+	if !errors.Is(err, want) {
+		t.Fatalf("expected %v, got %v", want, err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package saferand
+
+// NewSyscallSource returns a cryptographically secure Source.
+//
+// The getrandom(2) system call is only used on Linux. On other
+// platforms, it is equivalent to NewSource.
+func NewSyscallSource() Source {
+	return NewSource()
+}
@@ -0,0 +1,137 @@
+package saferand
+
+import (
+	"math"
+	"time"
+)
+
+// Duration returns a uniform random duration in [min, max).
+//
+// It returns min if min == max and panics if min > max.
+func Duration(min, max time.Duration) time.Duration { return defaultRand.Duration(min, max) }
+
+// Duration returns a uniform random duration in [min, max).
+//
+// It returns min if min == max and panics if min > max.
+func (r *Rand) Duration(min, max time.Duration) time.Duration {
+	if min > max {
+		panic("invalid argument to Duration")
+	}
+	if min == max {
+		return min
+	}
+	// max-min can overflow int64, but always fits in a uint64.
+	d := uint64(max) - uint64(min)
+	return min + time.Duration(r.Uint64n(d))
+}
+
+// TimeBetween returns a uniform random instant in [start, end).
+//
+// It returns start if start and end are equal and panics if
+// start is after end.
+func TimeBetween(start, end time.Time) time.Time { return defaultRand.TimeBetween(start, end) }
+
+// TimeBetween returns a uniform random instant in [start, end).
+//
+// The result has the same location as start. The interval may
+// be wider than the roughly 292 years representable by
+// a time.Duration.
+//
+// It returns start if start and end are equal and panics if
+// start is after end.
+func (r *Rand) TimeBetween(start, end time.Time) time.Time {
+	if start.After(end) {
+		panic("invalid argument to TimeBetween")
+	}
+	if start.Equal(end) {
+		return start
+	}
+	if d := end.Sub(start); d < math.MaxInt64 {
+		return start.Add(time.Duration(r.Int63n(int64(d))))
+	}
+
+	// end.Sub saturated, so choose a whole number of seconds
+	// and nanoseconds separately and reject instants after
+	// end.
+	//
+	// At least half of the candidates are accepted.
+	sec := start.Unix()
+	nsec := int64(start.Nanosecond())
+	span := uint64(end.Unix()) - uint64(sec)
+	for {
+		s := int64(r.Uint64n(span + 1))
+		n := int64(r.Uint64n(1e9))
+		t := time.Unix(sec+s, nsec+n)
+		if t.Before(end) {
+			return t.In(start.Location())
+		}
+	}
+}
+
+// Jitter returns d perturbed by a uniform random amount in
+// [-fraction*d, fraction*d).
+//
+// The result is never negative. It panics if fraction < 0.
+func Jitter(d time.Duration, fraction float64) time.Duration {
+	return defaultRand.Jitter(d, fraction)
+}
+
+// Jitter returns d perturbed by a uniform random amount in
+// [-fraction*d, fraction*d).
+//
+// For example, Jitter(time.Minute, 0.1) returns a duration in
+// [54s, 66s). The result is clamped to [0, math.MaxInt64].
+//
+// It panics if fraction < 0.
+func (r *Rand) Jitter(d time.Duration, fraction float64) time.Duration {
+	if !(fraction >= 0) {
+		panic("invalid argument to Jitter")
+	}
+	var delta time.Duration
+	if f := math.Abs(float64(d) * fraction); f >= math.MaxInt64 {
+		delta = math.MaxInt64
+	} else {
+		delta = time.Duration(f)
+	}
+	lo := d - delta
+	if lo > d {
+		lo = math.MinInt64
+	}
+	hi := d + delta
+	if hi < d {
+		hi = math.MaxInt64
+	}
+	x := r.Duration(lo, hi)
+	if x < 0 {
+		return 0
+	}
+	return x
+}
+
+// Backoff returns a random delay before retry number attempt,
+// using exponential backoff with full jitter.
+//
+// It panics if base < 0, max < 0, or attempt < 0.
+func Backoff(base, max time.Duration, attempt int) time.Duration {
+	return defaultRand.Backoff(base, max, attempt)
+}
+
+// Backoff returns a random delay before retry number attempt,
+// using exponential backoff with full jitter.
+//
+// The result is uniform in [0, min(base*2^attempt, max)].
+// Attempts are numbered from zero. base*2^attempt is computed
+// without overflow, so large attempts return values up to max.
+//
+// It panics if base < 0, max < 0, or attempt < 0.
+func (r *Rand) Backoff(base, max time.Duration, attempt int) time.Duration {
+	if base < 0 || max < 0 || attempt < 0 {
+		panic("invalid argument to Backoff")
+	}
+	ceil := max
+	if attempt < 63 && base <= max>>attempt {
+		ceil = base << attempt
+	}
+	// ceil+1 is at most 2^63, so it fits in a uint64.
+	return time.Duration(r.Uint64n(uint64(ceil) + 1))
+}
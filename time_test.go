@@ -0,0 +1,219 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	for _, tc := range []struct {
+		min, max time.Duration
+	}{
+		{0, 1},
+		{0, time.Second},
+		{-time.Hour, -time.Minute},
+		{-time.Hour, time.Hour},
+		{0, math.MaxInt64},
+		{math.MinInt64, math.MaxInt64},
+		{math.MinInt64, math.MinInt64 + 1},
+	} {
+		for i := 0; i < 1000; i++ {
+			d := Duration(tc.min, tc.max)
+			if d < tc.min || d >= tc.max {
+				t.Fatalf("Duration(%d, %d) = %d", tc.min, tc.max, d)
+			}
+		}
+	}
+
+	if d := Duration(time.Second, time.Second); d != time.Second {
+		t.Fatalf("expected %s, got %s", time.Second, d)
+	}
+
+	counts := make([]int, 10)
+	for i := 0; i < 100000; i++ {
+		counts[Duration(-5, 5)+5]++
+	}
+	checkUniform(t, counts)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic")
+		}
+	}()
+	Duration(time.Second, 0)
+}
+
+func TestTimeBetween(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("unable to load time zone: %v", err)
+	}
+	for _, tc := range []struct {
+		name       string
+		start, end time.Time
+	}{
+		{
+			"DST",
+			time.Date(2021, time.March, 14, 1, 0, 0, 0, ny),
+			time.Date(2021, time.March, 14, 4, 0, 0, 0, ny),
+		},
+		{
+			"multi-year",
+			time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"centuries",
+			time.Date(1, time.January, 1, 0, 0, 0, 0, ny),
+			time.Date(9999, time.December, 31, 23, 59, 59, 999999999, time.UTC),
+		},
+		{
+			"nanosecond",
+			time.Date(2000, time.January, 1, 0, 0, 0, 5, time.UTC),
+			time.Date(2000, time.January, 1, 0, 0, 0, 6, time.UTC),
+		},
+	} {
+		span := tc.end.Sub(tc.start)
+		var halves [2]int
+		for i := 0; i < 10000; i++ {
+			x := TimeBetween(tc.start, tc.end)
+			if x.Before(tc.start) || !x.Before(tc.end) {
+				t.Fatalf("%s: out of range: %s", tc.name, x)
+			}
+			if x.Location() != tc.start.Location() {
+				t.Fatalf("%s: expected location %s, got %s",
+					tc.name, tc.start.Location(), x.Location())
+			}
+			if span == math.MaxInt64 {
+				// Compare against the midpoint in seconds.
+				mid := tc.start.Unix()/2 + tc.end.Unix()/2
+				if x.Unix() >= mid {
+					halves[1]++
+				} else {
+					halves[0]++
+				}
+			} else if x.Sub(tc.start) >= span/2 {
+				halves[1]++
+			} else {
+				halves[0]++
+			}
+		}
+		if span > 1 {
+			checkUniform(t, halves[:])
+		}
+	}
+
+	now := time.Now()
+	if x := TimeBetween(now, now); !x.Equal(now) {
+		t.Fatalf("expected %s, got %s", now, x)
+	}
+	checkPanics(t, "TimeBetween", func() { TimeBetween(now.Add(1), now) })
+}
+
+func TestJitter(t *testing.T) {
+	for _, tc := range []struct {
+		d        time.Duration
+		fraction float64
+		lo, hi   time.Duration
+	}{
+		{time.Minute, 0.1, 54 * time.Second, 66 * time.Second},
+		{time.Second, 0, time.Second, time.Second + 1},
+		{time.Second, 1, 0, 2 * time.Second},
+		{time.Second, 3, 0, 4 * time.Second},
+		{0, 0.5, 0, 1},
+		{math.MaxInt64, 0.5, math.MaxInt64 / 2, math.MaxInt64},
+		{math.MaxInt64, 1e300, 0, math.MaxInt64},
+	} {
+		var sum float64
+		const n = 100000
+		for i := 0; i < n; i++ {
+			x := Jitter(tc.d, tc.fraction)
+			if x < tc.lo || (x >= tc.hi && tc.hi != math.MaxInt64) {
+				t.Fatalf("Jitter(%s, %g): %s not in [%s, %s)",
+					tc.d, tc.fraction, x, tc.lo, tc.hi)
+			}
+			sum += float64(x)
+		}
+		if tc.fraction > 1 || tc.d == math.MaxInt64 {
+			// Clamping skews the mean.
+			continue
+		}
+		// The mean should be within six standard errors of d.
+		mean := sum / n
+		se := float64(tc.d) * tc.fraction / math.Sqrt(3*n)
+		if math.Abs(mean-float64(tc.d)) > 6*se+1 {
+			t.Errorf("Jitter(%s, %g): mean %g, expected %d",
+				tc.d, tc.fraction, mean, tc.d)
+		}
+	}
+	checkPanics(t, "Jitter(-1)", func() { Jitter(time.Second, -1) })
+	checkPanics(t, "Jitter(NaN)", func() { Jitter(time.Second, math.NaN()) })
+}
+
+func TestBackoff(t *testing.T) {
+	const (
+		base = 10 * time.Millisecond
+		max  = 5 * time.Second
+	)
+	prev := -1.0
+	for attempt := 0; attempt < 20; attempt++ {
+		ceil := base << attempt
+		if ceil > max {
+			ceil = max
+		}
+		var sum float64
+		const n = 20000
+		for i := 0; i < n; i++ {
+			d := Backoff(base, max, attempt)
+			if d < 0 || d > ceil {
+				t.Fatalf("Backoff(%s, %s, %d): %s not in [0, %s]",
+					base, max, attempt, d, ceil)
+			}
+			sum += float64(d)
+		}
+		// The mean is ceil/2 with standard error
+		// ceil/sqrt(12n).
+		mean := sum / n
+		se := float64(ceil) / math.Sqrt(12*n)
+		if math.Abs(mean-float64(ceil)/2) > 6*se {
+			t.Errorf("Backoff(%s, %s, %d): mean %g, expected %g",
+				base, max, attempt, mean, float64(ceil)/2)
+		}
+		if ceil < max && !(mean > prev) {
+			t.Errorf("Backoff(%s, %s, %d): mean %g did not grow from %g",
+				base, max, attempt, mean, prev)
+		}
+		prev = mean
+	}
+
+	// Large attempts and bases do not overflow.
+	for _, tc := range []struct {
+		base, max time.Duration
+		attempt   int
+	}{
+		{time.Second, time.Hour, 62},
+		{time.Second, time.Hour, 63},
+		{time.Second, time.Hour, math.MaxInt},
+		{math.MaxInt64, math.MaxInt64, 1},
+		{math.MaxInt64 / 2, math.MaxInt64, 1},
+		{1, math.MaxInt64, 62},
+	} {
+		for i := 0; i < 100; i++ {
+			if d := Backoff(tc.base, tc.max, tc.attempt); d < 0 || d > tc.max {
+				t.Fatalf("Backoff(%d, %d, %d): %d not in [0, max]",
+					tc.base, tc.max, tc.attempt, d)
+			}
+		}
+	}
+	if d := Backoff(0, time.Second, 5); d != 0 {
+		t.Fatalf("Backoff(0, 1s, 5): got %s", d)
+	}
+	if d := Backoff(time.Second, 0, 5); d != 0 {
+		t.Fatalf("Backoff(1s, 0, 5): got %s", d)
+	}
+
+	checkPanics(t, "Backoff(-1, 1s, 0)", func() { Backoff(-1, time.Second, 0) })
+	checkPanics(t, "Backoff(1s, -1, 0)", func() { Backoff(time.Second, -1, 0) })
+	checkPanics(t, "Backoff(1s, 1s, -1)", func() { Backoff(time.Second, time.Second, -1) })
+}
@@ -0,0 +1,359 @@
+package saferand
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"math"
+	"math/big"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// alphanumeric is the alphabet used by Token.
+const alphanumeric = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// printableASCII is the alphabet used by PrintableASCII.
+const printableASCII = "!\"#$%&'()*+,-./0123456789:;<=>?@" +
+	"ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`" +
+	"abcdefghijklmnopqrstuvwxyz{|}~"
+
+// readable is the alphabet used by ReadableCode: the digits
+// and uppercase letters without 0, 1, I, L, and O.
+const readable = "23456789ABCDEFGHJKMNPQRSTUVWXYZ"
+
+// Token returns a random string of n characters drawn
+// uniformly from [A-Za-z0-9].
+//
+// It panics if n < 0.
+func Token(n int) string { return defaultRand.Token(n) }
+
+// PrintableASCII returns a random string of n characters drawn
+// uniformly from the printable ASCII characters 0x21 through
+// 0x7e.
+//
+// It panics if n < 0.
+func PrintableASCII(n int) string { return defaultRand.PrintableASCII(n) }
+
+// ReadableCode returns a random string of n characters that
+// are hard to confuse when read or typed by a person.
+//
+// It panics if n < 0.
+func ReadableCode(n int) string { return defaultRand.ReadableCode(n) }
+
+// TokenFrom returns a random string of n characters drawn
+// uniformly from alphabet.
+//
+// It panics if n < 0 or alphabet is empty.
+func TokenFrom(n int, alphabet string) string { return defaultRand.TokenFrom(n, alphabet) }
+
+// Token returns a random string of n characters drawn
+// uniformly from [A-Za-z0-9].
+//
+// It panics if n < 0.
+func (r *Rand) Token(n int) string {
+	return r.TokenFrom(n, alphanumeric)
+}
+
+// PrintableASCII returns a random string of n characters drawn
+// uniformly from the printable ASCII characters 0x21 through
+// 0x7e.
+//
+// The result does not contain spaces or control characters.
+// Like TokenFrom, it rejects bytes that would bias the result
+// toward some of the 94 characters.
+//
+// It panics if n < 0.
+func (r *Rand) PrintableASCII(n int) string {
+	if n < 0 {
+		panic("invalid argument to PrintableASCII")
+	}
+	return r.TokenFrom(n, printableASCII)
+}
+
+// ReadableCode returns a random string of n characters that
+// are hard to confuse when read or typed by a person.
+//
+// Like Crockford's base32, the characters are drawn uniformly
+// from the digits and uppercase letters, excluding 0, 1, I, L,
+// and O. L is excluded because its lowercase form looks like 1
+// and I. That leaves 31 symbols, about 4.95 bits of entropy per
+// character.
+//
+// It panics if n < 0.
+func (r *Rand) ReadableCode(n int) string {
+	if n < 0 {
+		panic("invalid argument to ReadableCode")
+	}
+	return r.TokenFrom(n, readable)
+}
+
+// TokenFrom returns a random string of n characters drawn
+// uniformly from alphabet.
+//
+// Each character in alphabet is a separate symbol, so
+// duplicated characters are proportionally more likely.
+//
+// It panics if n < 0 or alphabet is empty.
+func (r *Rand) TokenFrom(n int, alphabet string) string {
+	if n < 0 {
+		panic("invalid argument to TokenFrom")
+	}
+	symbols := []rune(alphabet)
+	if len(symbols) == 0 {
+		panic("invalid argument to TokenFrom")
+	}
+
+	var sb strings.Builder
+	sb.Grow(n)
+	if len(symbols) > 256 {
+		for i := 0; i < n; i++ {
+			sb.WriteRune(symbols[r.Uint32n(uint32(len(symbols)))])
+		}
+		return sb.String()
+	}
+
+	// Draw bytes in bulk and reject those that would bias the
+	// result.
+	//
+	// limit is the largest multiple of len(symbols) <= 256.
+	limit := 256 - 256%len(symbols)
+	buf := make([]byte, n)
+	for n > 0 {
+		r.fill(buf[:n])
+		for _, b := range buf[:n] {
+			if int(b) >= limit {
+				continue
+			}
+			sb.WriteRune(symbols[int(b)%len(symbols)])
+			n--
+		}
+	}
+	wipe(buf)
+	return sb.String()
+}
+
+const (
+	surrogateMin = 0xd800
+	surrogateMax = 0xdfff
+	// numScalars is the number of Unicode scalar values: the
+	// code points up to utf8.MaxRune, excluding surrogates.
+	numScalars = utf8.MaxRune + 1 - (surrogateMax - surrogateMin + 1)
+)
+
+// UTF8String returns a random string of n code points, encoded
+// as UTF-8.
+//
+// It panics if n < 0.
+func UTF8String(n int) string { return defaultRand.UTF8String(n) }
+
+// UTF8String returns a random string of n code points, encoded
+// as UTF-8.
+//
+// Each code point is drawn uniformly from the Unicode scalar
+// values, 0 through utf8.MaxRune excluding the surrogates
+// U+D800 through U+DFFF. The result is always valid UTF-8, but
+// it includes unassigned code points, control characters, and
+// NUL. Since most scalar values are outside the Basic
+// Multilingual Plane, most code points take 4 bytes.
+//
+// It panics if n < 0.
+func (r *Rand) UTF8String(n int) string {
+	if n < 0 {
+		panic("invalid argument to UTF8String")
+	}
+	var sb strings.Builder
+	sb.Grow(n * utf8.UTFMax)
+	for i := 0; i < n; i++ {
+		c := rune(r.Uint32n(numScalars))
+		if c >= surrogateMin {
+			c += surrogateMax - surrogateMin + 1
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// RuneIn returns a rune chosen uniformly from the code points
+// in rt.
+//
+// It panics if rt is nil or empty.
+func RuneIn(rt *unicode.RangeTable) rune { return defaultRand.RuneIn(rt) }
+
+// RuneIn returns a rune chosen uniformly from the code points
+// in rt.
+//
+// Every code point covered by rt's 16-bit and 32-bit ranges,
+// including their strides, is equally likely. For example,
+// RuneIn(unicode.Han) returns a random Han ideograph. It walks
+// the table for each call, so it takes time proportional to the
+// number of ranges.
+//
+// It panics if rt is nil or empty.
+func (r *Rand) RuneIn(rt *unicode.RangeTable) rune {
+	if rt == nil {
+		panic("invalid argument to RuneIn")
+	}
+	var total uint64
+	for _, rg := range rt.R16 {
+		total += uint64((rg.Hi-rg.Lo)/rg.Stride) + 1
+	}
+	for _, rg := range rt.R32 {
+		total += uint64((rg.Hi-rg.Lo)/rg.Stride) + 1
+	}
+	if total == 0 {
+		panic("invalid argument to RuneIn")
+	}
+
+	k := r.Uint64n(total)
+	for _, rg := range rt.R16 {
+		n := uint64((rg.Hi-rg.Lo)/rg.Stride) + 1
+		if k < n {
+			return rune(rg.Lo) + rune(k)*rune(rg.Stride)
+		}
+		k -= n
+	}
+	for _, rg := range rt.R32 {
+		n := uint64((rg.Hi-rg.Lo)/rg.Stride) + 1
+		if k < n {
+			return rune(rg.Lo) + rune(k)*rune(rg.Stride)
+		}
+		k -= n
+	}
+	panic("unreachable")
+}
+
+// HexToken returns the lowercase hexadecimal encoding of n
+// random bytes.
+//
+// The result is 2*n characters long. It panics if n < 0.
+func HexToken(n int) string { return defaultRand.HexToken(n) }
+
+// HexToken returns the lowercase hexadecimal encoding of n
+// random bytes.
+//
+// The bytes are read with a single call to the Rand's Source.
+// The result is 2*n characters long. It panics if n < 0.
+func (r *Rand) HexToken(n int) string {
+	if n < 0 {
+		panic("invalid argument to HexToken")
+	}
+	b := make([]byte, n)
+	r.fill(b)
+	s := hex.EncodeToString(b)
+	wipe(b)
+	return s
+}
+
+// base32Encoding is the unpadded RFC 4648 base32 encoding.
+var base32Encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Base32 returns the unpadded RFC 4648 base32 encoding of n
+// random bytes.
+//
+// It panics if n < 0.
+func Base32(n int) string { return defaultRand.Base32(n) }
+
+// Base32 returns the unpadded RFC 4648 base32 encoding of n
+// random bytes.
+//
+// Unlike Token, the result contains exactly 8*n bits of
+// entropy. It uses the alphabet [A-Z2-7] and is
+// ceil(8*n/5) characters long.
+//
+// It panics if n < 0.
+func (r *Rand) Base32(n int) string {
+	if n < 0 {
+		panic("invalid argument to Base32")
+	}
+	b := make([]byte, n)
+	r.fill(b)
+	s := base32Encoding.EncodeToString(b)
+	wipe(b)
+	return s
+}
+
+// Salt returns the standard, padded base64 encoding of n
+// random bytes.
+//
+// It panics if n < 0.
+func Salt(n int) string { return defaultRand.Salt(n) }
+
+// Salt returns the standard, padded base64 encoding of n
+// random bytes.
+//
+// It is suitable for password hashing schemes that store the
+// salt as text. The bytes are read with a single call to the
+// Rand's Source.
+//
+// It panics if n < 0.
+func (r *Rand) Salt(n int) string {
+	if n < 0 {
+		panic("invalid argument to Salt")
+	}
+	b := r.SaltRaw(n)
+	s := base64.StdEncoding.EncodeToString(b)
+	wipe(b)
+	return s
+}
+
+// SaltRaw returns n random bytes for use as a salt.
+//
+// It panics if n < 0.
+func SaltRaw(n int) []byte { return defaultRand.SaltRaw(n) }
+
+// SaltRaw returns n random bytes for use as a salt.
+//
+// The bytes are read with a single call to the Rand's Source.
+//
+// It panics if n < 0.
+func (r *Rand) SaltRaw(n int) []byte {
+	if n < 0 {
+		panic("invalid argument to SaltRaw")
+	}
+	b := make([]byte, n)
+	r.fill(b)
+	return b
+}
+
+// Base62 returns the base62 encoding of n random bytes.
+//
+// It panics if n < 0.
+func Base62(n int) string { return defaultRand.Base62(n) }
+
+// Base62 returns the base62 encoding of n random bytes.
+//
+// Unlike Token, the result contains exactly 8*n bits of
+// entropy. The bytes are encoded as a big-endian integer using
+// the alphabet [0-9a-zA-Z], left padded with zeros to
+// ceil(8*n/log2(62)) characters so that the length only
+// depends on n.
+//
+// It panics if n < 0.
+func (r *Rand) Base62(n int) string {
+	if n < 0 {
+		panic("invalid argument to Base62")
+	}
+	if n == 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	r.fill(b)
+	x := new(big.Int).SetBytes(b)
+	wipe(b)
+	s := x.Text(62)
+	x.SetInt64(0)
+
+	width := base62Len(n)
+	if len(s) >= width {
+		return s
+	}
+	return strings.Repeat("0", width-len(s)) + s
+}
+
+// base62Len returns the number of base62 digits needed to
+// encode any n byte integer.
+func base62Len(n int) int {
+	return int(math.Ceil(float64(8*n) / math.Log2(62)))
+}
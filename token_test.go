@@ -0,0 +1,402 @@
+package saferand
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/base64"
+	"math/big"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+func TestToken(t *testing.T) {
+	for _, n := range []int{0, 1, 16, 100} {
+		s := Token(n)
+		if len(s) != n {
+			t.Fatalf("Token(%d): got %d characters", n, len(s))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(alphanumeric, c) {
+				t.Fatalf("Token(%d): unexpected character %q", n, c)
+			}
+		}
+	}
+
+	counts := make([]int, len(alphanumeric))
+	for i := 0; i < 1000; i++ {
+		for _, c := range Token(100) {
+			counts[strings.IndexRune(alphanumeric, c)]++
+		}
+	}
+	checkUniform(t, counts)
+}
+
+func TestPrintableASCII(t *testing.T) {
+	if len(printableASCII) != 0x7e-0x21+1 {
+		t.Fatalf("alphabet has %d characters", len(printableASCII))
+	}
+	for i := 0; i < len(printableASCII); i++ {
+		if c := printableASCII[i]; c != byte(0x21+i) {
+			t.Fatalf("alphabet[%d]: got %q, expected %q", i, c, 0x21+i)
+		}
+	}
+
+	for _, n := range []int{0, 1, 16, 100} {
+		s := PrintableASCII(n)
+		if len(s) != n {
+			t.Fatalf("PrintableASCII(%d): got %d characters", n, len(s))
+		}
+		for i := 0; i < len(s); i++ {
+			if c := s[i]; c < 0x21 || c > 0x7e {
+				t.Fatalf("PrintableASCII(%d): unexpected character %q", n, c)
+			}
+		}
+	}
+
+	counts := make([]int, len(printableASCII))
+	for i := 0; i < 1000; i++ {
+		s := PrintableASCII(100)
+		for j := 0; j < len(s); j++ {
+			counts[s[j]-0x21]++
+		}
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "PrintableASCII(-1)", func() { PrintableASCII(-1) })
+}
+
+func TestReadableCode(t *testing.T) {
+	if len(readable) != 31 {
+		t.Fatalf("alphabet has %d characters", len(readable))
+	}
+
+	for _, n := range []int{0, 1, 16, 100} {
+		if s := ReadableCode(n); len(s) != n {
+			t.Fatalf("ReadableCode(%d): got %d characters", n, len(s))
+		}
+	}
+
+	counts := make([]int, len(readable))
+	for i := 0; i < 1000; i++ {
+		s := ReadableCode(100)
+		if strings.ContainsAny(s, "0O1lIoiL") {
+			t.Fatalf("ReadableCode(100): ambiguous character in %q", s)
+		}
+		for j := 0; j < len(s); j++ {
+			k := strings.IndexByte(readable, s[j])
+			if k < 0 {
+				t.Fatalf("ReadableCode(100): unexpected character %q", s[j])
+			}
+			counts[k]++
+		}
+	}
+	checkUniform(t, counts)
+
+	checkPanics(t, "ReadableCode(-1)", func() { ReadableCode(-1) })
+}
+
+func TestTokenFrom(t *testing.T) {
+	for _, alphabet := range []string{
+		"a",
+		"ab",
+		"abc",
+		"0123456789abcdef",
+		"αβγδε",
+		strings.Repeat("x", 300) + "y",
+	} {
+		symbols := []rune(alphabet)
+		s := TokenFrom(50, alphabet)
+		if n := utf8.RuneCountInString(s); n != 50 {
+			t.Fatalf("%q: got %d characters", alphabet, n)
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(alphabet, c) {
+				t.Fatalf("%q: unexpected character %q", alphabet, c)
+			}
+		}
+		if len(symbols) > 10 {
+			continue
+		}
+		index := make(map[rune]int)
+		for i, c := range symbols {
+			index[c] = i
+		}
+		counts := make([]int, len(symbols))
+		for i := 0; i < 1000; i++ {
+			for _, c := range TokenFrom(50, alphabet) {
+				counts[index[c]]++
+			}
+		}
+		checkUniform(t, counts)
+	}
+}
+
+func TestTokenFromPanics(t *testing.T) {
+	for _, fn := range []func(){
+		func() { TokenFrom(-1, "abc") },
+		func() { TokenFrom(1, "") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatal("expected a panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestUTF8String(t *testing.T) {
+	if numScalars != 1112064 {
+		t.Fatalf("got %d scalar values", numScalars)
+	}
+
+	var planes [17]int
+	for _, n := range []int{0, 1, 10, 1000} {
+		s := UTF8String(n)
+		if !utf8.ValidString(s) {
+			t.Fatalf("UTF8String(%d): invalid UTF-8: %q", n, s)
+		}
+		if got := utf8.RuneCountInString(s); got != n {
+			t.Fatalf("UTF8String(%d): got %d runes", n, got)
+		}
+	}
+	for i := 0; i < 200; i++ {
+		s := UTF8String(1000)
+		if !utf8.ValidString(s) {
+			t.Fatalf("invalid UTF-8: %q", s)
+		}
+		for _, c := range s {
+			if c >= surrogateMin && c <= surrogateMax {
+				t.Fatalf("got surrogate %U", c)
+			}
+			if c < 0 || c > utf8.MaxRune {
+				t.Fatalf("got invalid rune %U", c)
+			}
+			planes[c>>16]++
+		}
+	}
+	// Each plane is equally likely, except that plane 0 is
+	// missing the 2048 surrogates.
+	probs := make([]float64, len(planes))
+	for i := range probs {
+		probs[i] = 0x10000 / float64(numScalars)
+	}
+	probs[0] = (0x10000 - 0x800) / float64(numScalars)
+	checkChiSquare(t, planes[:], probs)
+
+	// The code points on either side of the gap are reachable.
+	// Uint32n returns k for x = floor(((k+1)*2^32 - 1) / n),
+	// since Uint32 uses the high half of the Source's output.
+	index := func(k uint64) uint64 {
+		return ((k+1)<<32 - 1) / numScalars << 32
+	}
+	r := NewWithSource(&seqSource{vals: []uint64{
+		index(surrogateMin - 1),
+		index(surrogateMin),
+	}})
+	if s := r.UTF8String(2); s != "\ud7ff\ue000" {
+		t.Fatalf("got %q, expected %q", s, "\ud7ff\ue000")
+	}
+
+	checkPanics(t, "UTF8String(-1)", func() { UTF8String(-1) })
+}
+
+func TestRuneIn(t *testing.T) {
+	for _, rt := range []*unicode.RangeTable{
+		unicode.Han,
+		unicode.Greek,
+		unicode.Cyrillic,
+		unicode.Upper,
+		unicode.Nd,
+		unicode.Cherokee,
+	} {
+		for i := 0; i < 10000; i++ {
+			if c := RuneIn(rt); !unicode.Is(rt, c) {
+				t.Fatalf("RuneIn: %U is not in the table", c)
+			}
+		}
+	}
+
+	// Strided ranges in both halves of the table.
+	rt := &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 'a', Hi: 'e', Stride: 2},
+			{Lo: 'x', Hi: 'x', Stride: 1},
+		},
+		R32: []unicode.Range32{
+			{Lo: 0x10000, Hi: 0x10009, Stride: 3},
+		},
+	}
+	want := []rune{'a', 'c', 'e', 'x', 0x10000, 0x10003, 0x10006, 0x10009}
+	index := make(map[rune]int)
+	for i, c := range want {
+		index[c] = i
+	}
+	counts := make([]int, len(want))
+	for i := 0; i < 80000; i++ {
+		c := RuneIn(rt)
+		j, ok := index[c]
+		if !ok {
+			t.Fatalf("RuneIn: unexpected rune %U", c)
+		}
+		counts[j]++
+	}
+	checkUniform(t, counts)
+
+	// Han's ranges are very different sizes, so check that the
+	// result is uniform over code points, not ranges.
+	size := func(lo, hi, stride uint32) int { return int((hi-lo)/stride) + 1 }
+	var total int
+	for _, rg := range unicode.Han.R16 {
+		total += size(uint32(rg.Lo), uint32(rg.Hi), uint32(rg.Stride))
+	}
+	for _, rg := range unicode.Han.R32 {
+		total += size(rg.Lo, rg.Hi, rg.Stride)
+	}
+	last := unicode.Han.R16[len(unicode.Han.R16)-1]
+	p := float64(size(uint32(last.Lo), uint32(last.Hi), uint32(last.Stride))) / float64(total)
+	var in [2]int
+	for i := 0; i < 20000; i++ {
+		c := RuneIn(unicode.Han)
+		in[b2i(c >= rune(last.Lo) && c <= rune(last.Hi))]++
+	}
+	checkChiSquare(t, in[:], []float64{1 - p, p})
+
+	checkPanics(t, "RuneIn(nil)", func() { RuneIn(nil) })
+	checkPanics(t, "RuneIn(empty)", func() { RuneIn(&unicode.RangeTable{}) })
+}
+
+func TestHexToken(t *testing.T) {
+	for _, n := range []int{0, 1, 16, 100} {
+		s := HexToken(n)
+		if len(s) != 2*n {
+			t.Fatalf("HexToken(%d): got %d characters", n, len(s))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				t.Fatalf("HexToken(%d): unexpected character %q", n, c)
+			}
+		}
+	}
+	if HexToken(16) == HexToken(16) {
+		t.Fatal("two successive calls returned the same token")
+	}
+	checkPanics(t, "HexToken(-1)", func() { HexToken(-1) })
+}
+
+func TestSalt(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 16, 33} {
+		want := make([]byte, n)
+		if _, err := rand.Read(want); err != nil {
+			t.Fatal(err)
+		}
+		r := NewWithSource(NewSourceFromReader(bytes.NewReader(want)))
+		s := r.Salt(n)
+		if len(s) != base64.StdEncoding.EncodedLen(n) {
+			t.Fatalf("Salt(%d): got %d characters", n, len(s))
+		}
+		got, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			t.Fatalf("Salt(%d): %v", n, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Salt(%d): expected %x, got %x", n, want, got)
+		}
+		if b := SaltRaw(n); len(b) != n {
+			t.Fatalf("SaltRaw(%d): got %d bytes", n, len(b))
+		}
+	}
+	if Salt(16) == Salt(16) {
+		t.Fatal("two successive calls returned the same salt")
+	}
+	if bytes.Equal(SaltRaw(16), SaltRaw(16)) {
+		t.Fatal("two successive calls returned the same salt")
+	}
+	checkPanics(t, "Salt(-1)", func() { Salt(-1) })
+	checkPanics(t, "SaltRaw(-1)", func() { SaltRaw(-1) })
+}
+
+func TestBase32(t *testing.T) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	for _, n := range []int{0, 1, 5, 16, 33} {
+		want := make([]byte, n)
+		if _, err := rand.Read(want); err != nil {
+			t.Fatal(err)
+		}
+		r := NewWithSource(NewSourceFromReader(bytes.NewReader(want)))
+		s := r.Base32(n)
+		if len(s) != (8*n+4)/5 {
+			t.Fatalf("Base32(%d): got %d characters", n, len(s))
+		}
+		for _, c := range s {
+			if !strings.ContainsRune(alphabet, c) {
+				t.Fatalf("Base32(%d): unexpected character %q", n, c)
+			}
+		}
+		got, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+		if err != nil {
+			t.Fatalf("Base32(%d): %v", n, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("Base32(%d): expected %x, got %x", n, want, got)
+		}
+	}
+	if Base32(16) == Base32(16) {
+		t.Fatal("two successive calls returned the same token")
+	}
+	checkPanics(t, "Base32(-1)", func() { Base32(-1) })
+}
+
+func TestBase62(t *testing.T) {
+	const alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	for _, n := range []int{0, 1, 2, 16, 32, 100} {
+		for _, want := range [][]byte{
+			make([]byte, n),                            // all zeros
+			bytes.Repeat([]byte{0xff}, n),              // all ones
+			append(make([]byte, n/2), Bytes(n-n/2)...), // leading zeros
+			Bytes(n),
+		} {
+			r := NewWithSource(NewSourceFromReader(bytes.NewReader(want)))
+			s := r.Base62(n)
+			if len(s) != base62Len(n) {
+				t.Fatalf("Base62(%d): got %d characters, expected %d",
+					n, len(s), base62Len(n))
+			}
+			for _, c := range s {
+				if !strings.ContainsRune(alphabet, c) {
+					t.Fatalf("Base62(%d): unexpected character %q", n, c)
+				}
+			}
+			if n == 0 {
+				continue
+			}
+			x, ok := new(big.Int).SetString(s, 62)
+			if !ok {
+				t.Fatalf("Base62(%d): invalid encoding: %q", n, s)
+			}
+			if x.BitLen() > 8*n {
+				t.Fatalf("Base62(%d): decoded to %d bits", n, x.BitLen())
+			}
+			got := x.FillBytes(make([]byte, n))
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Base62(%d): expected %x, got %x", n, want, got)
+			}
+		}
+	}
+	// base62Len should be minimal.
+	for n := 1; n < 100; n++ {
+		max := new(big.Int).Lsh(big.NewInt(1), uint(8*n))
+		if got := len(max.Sub(max, big.NewInt(1)).Text(62)); got != base62Len(n) {
+			t.Fatalf("base62Len(%d): got %d, expected %d", n, base62Len(n), got)
+		}
+	}
+	if Base62(16) == Base62(16) {
+		t.Fatal("two successive calls returned the same token")
+	}
+	checkPanics(t, "Base62(-1)", func() { Base62(-1) })
+}
@@ -0,0 +1,38 @@
+package saferand
+
+import (
+	"encoding/hex"
+)
+
+// UUIDv4 returns a random RFC 4122 version 4 UUID.
+func UUIDv4() [16]byte { return defaultRand.UUIDv4() }
+
+// UUIDv4String returns a random RFC 4122 version 4 UUID in its
+// canonical form: xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx.
+func UUIDv4String() string { return defaultRand.UUIDv4String() }
+
+// UUIDv4 returns a random RFC 4122 version 4 UUID.
+func (r *Rand) UUIDv4() [16]byte {
+	var u [16]byte
+	r.fill(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant 10
+	return u
+}
+
+// UUIDv4String returns a random RFC 4122 version 4 UUID in its
+// canonical form: xxxxxxxx-xxxx-4xxx-yxxx-xxxxxxxxxxxx.
+func (r *Rand) UUIDv4String() string {
+	u := r.UUIDv4()
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,39 @@
+package saferand
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestUUIDv4(t *testing.T) {
+	seen := make(map[[16]byte]bool)
+	for i := 0; i < 10000; i++ {
+		u := UUIDv4()
+		if v := u[6] >> 4; v != 4 {
+			t.Fatalf("%x: invalid version %d", u, v)
+		}
+		if v := u[8] >> 6; v != 2 {
+			t.Fatalf("%x: invalid variant %b", u, v)
+		}
+		if seen[u] {
+			t.Fatalf("%x: duplicate UUID", u)
+		}
+		seen[u] = true
+	}
+}
+
+var uuidRE = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestUUIDv4String(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 10000; i++ {
+		s := UUIDv4String()
+		if !uuidRE.MatchString(s) {
+			t.Fatalf("invalid UUID: %q", s)
+		}
+		if seen[s] {
+			t.Fatalf("%s: duplicate UUID", s)
+		}
+		seen[s] = true
+	}
+}
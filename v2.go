@@ -0,0 +1,28 @@
+//go:build go1.22
+
+package saferand
+
+import (
+	mathrandv2 "math/rand/v2"
+)
+
+// V2Source implements math/rand/v2.Source.
+//
+// The zero value reads from crypto/rand and is safe for
+// concurrent use by multiple goroutines.
+type V2Source struct{}
+
+var _ mathrandv2.Source = V2Source{}
+
+// NewV2 returns a math/rand/v2.Rand that generates
+// cryptographically secure random values.
+func NewV2() *mathrandv2.Rand {
+	return mathrandv2.New(V2Source{})
+}
+
+// Seed is a no-op.
+func (V2Source) Seed(_ uint64) {}
+
+func (V2Source) Uint64() uint64 {
+	return ExpSource{}.Uint64()
+}
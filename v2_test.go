@@ -0,0 +1,33 @@
+//go:build go1.22
+
+package saferand
+
+import (
+	mathrandv2 "math/rand/v2"
+	"testing"
+)
+
+func TestV2Source(t *testing.T) {
+	r := mathrandv2.New(V2Source{})
+	if r.Uint64() == r.Uint64() {
+		t.Fatal("two successive Uint64 values are identical")
+	}
+	for i := 0; i < 1000; i++ {
+		if x := r.Int64N(10); x < 0 || x >= 10 {
+			t.Fatalf("Int64N(10) out of range: %d", x)
+		}
+		if x := NewV2().IntN(10); x < 0 || x >= 10 {
+			t.Fatalf("IntN(10) out of range: %d", x)
+		}
+	}
+}
+
+func TestV2SourceSeed(t *testing.T) {
+	var src V2Source
+	src.Seed(1)
+	x := src.Uint64()
+	src.Seed(1)
+	if y := src.Uint64(); x == y {
+		t.Fatalf("Seed is not a no-op: got %#x twice", x)
+	}
+}
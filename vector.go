@@ -0,0 +1,69 @@
+package saferand
+
+import (
+	"math"
+)
+
+// minVectorNorm2 is the smallest squared norm that
+// UnitVector normalizes. Smaller Gaussian vectors are
+// resampled so that rounding error in the direction stays
+// negligible.
+const minVectorNorm2 = 1e-12
+
+// UnitVector3 returns a point chosen uniformly from the surface
+// of the unit sphere in three dimensions.
+func UnitVector3() [3]float64 { return defaultRand.UnitVector3() }
+
+// UnitVector returns a point chosen uniformly from the surface
+// of the unit sphere in d dimensions.
+//
+// It panics if d < 1.
+func UnitVector(d int) []float64 { return defaultRand.UnitVector(d) }
+
+// UnitVector3 returns a point chosen uniformly from the surface
+// of the unit sphere in three dimensions.
+//
+// It is UnitVector(3) without the allocation.
+func (r *Rand) UnitVector3() [3]float64 {
+	var v [3]float64
+	r.unitVector(v[:])
+	return v
+}
+
+// UnitVector returns a point chosen uniformly from the surface
+// of the unit sphere in d dimensions.
+//
+// It normalizes a vector of d independent standard normal
+// values, which is spherically symmetric. For d == 1, the
+// result is either -1 or 1.
+//
+// It panics if d < 1.
+func (r *Rand) UnitVector(d int) []float64 {
+	if d < 1 {
+		panic("invalid argument to UnitVector")
+	}
+	v := make([]float64, d)
+	r.unitVector(v)
+	return v
+}
+
+// unitVector fills v with a uniformly random unit vector.
+//
+// len(v) must be positive.
+func (r *Rand) unitVector(v []float64) {
+	for {
+		var n2 float64
+		for i := range v {
+			v[i] = r.NormFloat64()
+			n2 += v[i] * v[i]
+		}
+		if n2 < minVectorNorm2 {
+			continue
+		}
+		n := math.Sqrt(n2)
+		for i := range v {
+			v[i] /= n
+		}
+		return
+	}
+}
@@ -0,0 +1,78 @@
+package saferand
+
+import (
+	"math"
+	"testing"
+)
+
+func checkUnit(t *testing.T, v []float64) {
+	t.Helper()
+	var n2 float64
+	for _, x := range v {
+		n2 += x * x
+	}
+	if math.Abs(n2-1) > 1e-12 {
+		t.Fatalf("%v: squared norm is %g", v, n2)
+	}
+}
+
+func TestUnitVector3(t *testing.T) {
+	// Each octant covers 1/8 of the sphere.
+	var octants [8]int
+	var sum [3]float64
+	const n = 80000
+	for i := 0; i < n; i++ {
+		v := UnitVector3()
+		checkUnit(t, v[:])
+		o := 0
+		for j, x := range v {
+			if x >= 0 {
+				o |= 1 << j
+			}
+			sum[j] += x
+		}
+		octants[o]++
+	}
+	checkUniform(t, octants[:])
+
+	// Each coordinate is uniform on [-1, 1] (Archimedes), so
+	// its mean is 0 with variance 1/3.
+	for j, s := range sum {
+		if se := math.Sqrt(1.0 / 3 / n); math.Abs(s/n) > 6*se {
+			t.Errorf("coordinate %d: mean %g", j, s/n)
+		}
+	}
+}
+
+func TestUnitVector(t *testing.T) {
+	for _, d := range []int{1, 2, 4, 10, 100} {
+		for i := 0; i < 100; i++ {
+			v := UnitVector(d)
+			if len(v) != d {
+				t.Fatalf("UnitVector(%d): got %d coordinates", d, len(v))
+			}
+			checkUnit(t, v)
+		}
+	}
+
+	var signs [2]int
+	for i := 0; i < 10000; i++ {
+		v := UnitVector(1)
+		if v[0] != 1 && v[0] != -1 {
+			t.Fatalf("UnitVector(1): got %v", v)
+		}
+		signs[b2i(v[0] > 0)]++
+	}
+	checkUniform(t, signs[:])
+
+	// The angle of a 2-D unit vector is uniform.
+	var sectors [12]int
+	for i := 0; i < 60000; i++ {
+		v := UnitVector(2)
+		a := math.Atan2(v[1], v[0]) + math.Pi
+		sectors[int(a/(2*math.Pi)*12)%12]++
+	}
+	checkUniform(t, sectors[:])
+
+	checkPanics(t, "UnitVector(0)", func() { UnitVector(0) })
+}
@@ -0,0 +1,390 @@
+package saferand
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+var (
+	errNoWeights       = errors.New("saferand: no weights")
+	errInvalidWeight   = errors.New("saferand: weights must be finite and non-negative")
+	errZeroTotalWeight = errors.New("saferand: total weight must be positive")
+	errLabelMismatch   = errors.New("saferand: number of labels and probabilities differ")
+	errProbSum         = errors.New("saferand: probabilities must sum to 1")
+	errMixtureMismatch = errors.New("saferand: number of components and weights differ")
+	errNilComponent    = errors.New("saferand: nil mixture component")
+)
+
+// Weighted samples indices from a fixed discrete distribution.
+//
+// It uses Vose's alias method, so construction is O(n) and
+// each sample is O(1).
+//
+// A Weighted is safe for concurrent use by multiple goroutines.
+type Weighted struct {
+	// prob[i] is the probability of choosing i, given that i
+	// was the column drawn. Otherwise, alias[i] is chosen.
+	prob  []float64
+	alias []int
+}
+
+// NewWeighted creates a Weighted that samples index i with
+// probability proportional to weights[i].
+//
+// It returns an error if weights is empty, contains a negative
+// or non-finite weight, or if all weights are zero.
+func NewWeighted(weights []float64) (*Weighted, error) {
+	n := len(weights)
+	if n == 0 {
+		return nil, errNoWeights
+	}
+	var sum float64
+	for _, w := range weights {
+		if w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+			return nil, errInvalidWeight
+		}
+		sum += w
+	}
+	if sum <= 0 || math.IsInf(sum, 0) {
+		return nil, errZeroTotalWeight
+	}
+
+	w := &Weighted{
+		prob:  make([]float64, n),
+		alias: make([]int, n),
+	}
+
+	// Scale the weights so that the average is 1, then split
+	// them into those below and above the average.
+	scaled := make([]float64, n)
+	var small, large []int
+	for i, x := range weights {
+		scaled[i] = x * float64(n) / sum
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	// Pair each small column with a large column that fills
+	// the remainder.
+	for len(small) > 0 && len(large) > 0 {
+		l := small[len(small)-1]
+		small = small[:len(small)-1]
+		g := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		w.prob[l] = scaled[l]
+		w.alias[l] = g
+		scaled[g] = (scaled[g] + scaled[l]) - 1
+		if scaled[g] < 1 {
+			small = append(small, g)
+		} else {
+			large = append(large, g)
+		}
+	}
+
+	// Whatever remains is 1, modulo rounding error.
+	for _, g := range large {
+		w.prob[g] = 1
+		w.alias[g] = g
+	}
+	for _, l := range small {
+		w.prob[l] = 1
+		w.alias[l] = l
+	}
+	return w, nil
+}
+
+// Len returns the number of indices in the distribution.
+func (w *Weighted) Len() int {
+	return len(w.prob)
+}
+
+// Next returns a random index in [0, w.Len()).
+func (w *Weighted) Next(r *Rand) int {
+	i := int(r.Uint64n(uint64(len(w.prob))))
+	if r.Float64() < w.prob[i] {
+		return i
+	}
+	return w.alias[i]
+}
+
+// Categorical samples labels from a fixed discrete
+// distribution.
+//
+// It is a Weighted that maps each index to a label.
+//
+// A Categorical is safe for concurrent use by multiple
+// goroutines.
+type Categorical[T any] struct {
+	w      *Weighted
+	labels []T
+}
+
+// NewCategorical creates a Categorical that samples labels[i]
+// with probability probs[i].
+//
+// labels is copied. It returns an error if labels and probs
+// have different lengths, if probs is empty or contains a
+// negative or non-finite probability, or if probs does not sum
+// to 1 within 1e-9.
+func NewCategorical[T any](labels []T, probs []float64) (*Categorical[T], error) {
+	if len(labels) != len(probs) {
+		return nil, errLabelMismatch
+	}
+	w, err := NewWeighted(probs)
+	if err != nil {
+		return nil, err
+	}
+	var sum float64
+	for _, p := range probs {
+		sum += p
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		return nil, errProbSum
+	}
+	c := &Categorical[T]{
+		w:      w,
+		labels: make([]T, len(labels)),
+	}
+	copy(c.labels, labels)
+	return c, nil
+}
+
+// Len returns the number of labels in the distribution.
+func (c *Categorical[T]) Len() int {
+	return len(c.labels)
+}
+
+// Sample returns a random label.
+func (c *Categorical[T]) Sample(r *Rand) T {
+	return c.labels[c.w.Next(r)]
+}
+
+// Mixture samples from a weighted mixture of distributions.
+//
+// Each sample first chooses a component with a Weighted, then
+// draws from that component.
+//
+// A Mixture is safe for concurrent use by multiple goroutines
+// if each of its components is.
+type Mixture struct {
+	w          *Weighted
+	components []func(*Rand) float64
+}
+
+// NewMixture creates a Mixture that draws from components[i]
+// with probability proportional to weights[i].
+//
+// components is copied. A component is typically a method
+// value or closure over one of the distributions in this
+// package, like
+//
+//	func(r *Rand) float64 { return r.Normal(0, 1) }
+//
+// It returns an error if components and weights have different
+// lengths, if a component is nil, or if the weights are
+// invalid for NewWeighted.
+func NewMixture(components []func(*Rand) float64, weights []float64) (*Mixture, error) {
+	if len(components) != len(weights) {
+		return nil, errMixtureMismatch
+	}
+	for _, c := range components {
+		if c == nil {
+			return nil, errNilComponent
+		}
+	}
+	w, err := NewWeighted(weights)
+	if err != nil {
+		return nil, err
+	}
+	m := &Mixture{
+		w:          w,
+		components: make([]func(*Rand) float64, len(components)),
+	}
+	copy(m.components, components)
+	return m, nil
+}
+
+// Len returns the number of components in the mixture.
+func (m *Mixture) Len() int {
+	return len(m.components)
+}
+
+// Sample returns a random value from the mixture.
+func (m *Mixture) Sample(r *Rand) float64 {
+	return m.components[m.w.Next(r)](r)
+}
+
+// ChoiceCounts returns an index i chosen with probability
+// proportional to counts[i].
+//
+// It panics if counts contains a negative count or if the
+// total is zero or overflows a uint64.
+func ChoiceCounts(counts []int) int { return defaultRand.ChoiceCounts(counts) }
+
+// ChoiceCounts returns an index i chosen with probability
+// proportional to counts[i].
+//
+// Unlike NewWeighted, it uses exact integer arithmetic: it
+// draws a single uniform value in [0, total) and finds its
+// bucket with a binary search over the cumulative counts.
+// Indices with zero count are never chosen.
+//
+// It panics if counts contains a negative count or if the
+// total is zero or overflows a uint64.
+func (r *Rand) ChoiceCounts(counts []int) int {
+	cum := make([]uint64, len(counts))
+	var total uint64
+	for i, c := range counts {
+		if c < 0 {
+			panic("invalid argument to ChoiceCounts")
+		}
+		var carry uint64
+		total, carry = bits.Add64(total, uint64(c), 0)
+		if carry != 0 {
+			panic("invalid argument to ChoiceCounts")
+		}
+		cum[i] = total
+	}
+	if total == 0 {
+		panic("invalid argument to ChoiceCounts")
+	}
+	x := r.Uint64n(total)
+	return sort.Search(len(cum), func(i int) bool { return cum[i] > x })
+}
+
+// SampleLogits returns an index i chosen with probability
+// softmax(logits/temperature)[i].
+//
+// It panics if logits is empty, contains NaN or +Inf, or
+// contains only -Inf, or if temperature is negative or NaN.
+func SampleLogits(logits []float64, temperature float64) int {
+	return defaultRand.SampleLogits(logits, temperature)
+}
+
+// SampleLogits returns an index i chosen with probability
+// softmax(logits/temperature)[i].
+//
+// Higher temperatures flatten the distribution toward uniform.
+// Lower temperatures sharpen it toward the largest logit. A
+// temperature of 0 returns the index of the largest logit,
+// picking the lowest index on ties, without reading any
+// randomness. A temperature of +Inf chooses uniformly among the
+// finite logits. A logit of -Inf is never chosen.
+//
+// It subtracts the largest logit before exponentiating, so
+// large logits do not overflow. It does not allocate.
+//
+// It panics if logits is empty, contains NaN or +Inf, or
+// contains only -Inf, or if temperature is negative or NaN.
+func (r *Rand) SampleLogits(logits []float64, temperature float64) int {
+	if len(logits) == 0 || !(temperature >= 0) {
+		panic("invalid argument to SampleLogits")
+	}
+	best := 0
+	for i, x := range logits {
+		if math.IsNaN(x) || math.IsInf(x, 1) {
+			panic("invalid argument to SampleLogits")
+		}
+		if x > logits[best] {
+			best = i
+		}
+	}
+	max := logits[best]
+	if math.IsInf(max, -1) {
+		panic("invalid argument to SampleLogits")
+	}
+	if temperature == 0 {
+		return best
+	}
+
+	// The weights are recomputed in the second pass instead of
+	// stored. The results are identical, and it avoids
+	// allocating.
+	var total float64
+	for _, x := range logits {
+		total += logitWeight(x, max, temperature)
+	}
+	u := r.Float64() * total
+	last := best
+	for i, x := range logits {
+		w := logitWeight(x, max, temperature)
+		if w == 0 {
+			continue
+		}
+		if u < w {
+			return i
+		}
+		u -= w
+		last = i
+	}
+	// Rounding left u slightly above the sum of the weights.
+	return last
+}
+
+// logitWeight returns the unnormalized softmax weight of the
+// logit x.
+//
+// -Inf is handled separately because (-Inf - max) / +Inf is
+// NaN.
+func logitWeight(x, max, temperature float64) float64 {
+	if math.IsInf(x, -1) {
+		return 0
+	}
+	return math.Exp((x - max) / temperature)
+}
+
+// WeightedShuffle returns a copy of items in a random order in
+// which heavier items tend to appear earlier.
+//
+// The first item is chosen with probability proportional to
+// its weight, the second is chosen the same way from the
+// remaining items, and so on. Items with zero weight always
+// appear last, in uniformly random order.
+//
+// It panics if items and weights have different lengths, or if
+// any weight is negative or non-finite.
+func WeightedShuffle[T any](items []T, weights []float64) []T {
+	return weightedShuffle(defaultRand, items, weights)
+}
+
+func weightedShuffle[T any](r *Rand, items []T, weights []float64) []T {
+	if len(items) != len(weights) {
+		panic("invalid argument to WeightedShuffle")
+	}
+	// Efraimidis and Spirakis's exponential keys: sorting by
+	// -ln(U)/w is equivalent to repeatedly drawing without
+	// replacement in proportion to w.
+	keys := make([]float64, len(weights))
+	idx := make([]int, len(weights))
+	zero := 0
+	for i, w := range weights {
+		if !(w >= 0) || math.IsInf(w, 0) {
+			panic("invalid argument to WeightedShuffle")
+		}
+		if w == 0 {
+			keys[i] = math.Inf(1)
+			zero++
+		} else {
+			keys[i] = -math.Log(r.Float64Pos()) / w
+		}
+		idx[i] = i
+	}
+	sort.Slice(idx, func(i, j int) bool {
+		return keys[idx[i]] < keys[idx[j]]
+	})
+	// Zero-weight items all have infinite keys, so sort.Slice
+	// leaves them in an arbitrary order.
+	shuffleSlice(r, idx[len(idx)-zero:])
+
+	out := make([]T, len(items))
+	for i, j := range idx {
+		out[i] = items[j]
+	}
+	return out
+}
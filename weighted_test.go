@@ -0,0 +1,395 @@
+package saferand
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestWeighted(t *testing.T) {
+	for _, weights := range [][]float64{
+		{1},
+		{1, 1},
+		{1, 2, 3, 4},
+		{0, 5, 0, 1, 0.5},
+		{0.01, 1, 100},
+	} {
+		w, err := NewWeighted(weights)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Len() != len(weights) {
+			t.Fatalf("expected %d, got %d", len(weights), w.Len())
+		}
+		var sum float64
+		for _, x := range weights {
+			sum += x
+		}
+		probs := make([]float64, len(weights))
+		for i, x := range weights {
+			probs[i] = x / sum
+		}
+
+		r := New()
+		counts := make([]int, len(weights))
+		for i := 0; i < 2e5; i++ {
+			counts[w.Next(r)]++
+		}
+		checkChiSquare(t, counts, probs)
+	}
+}
+
+func TestWeightedLarge(t *testing.T) {
+	weights := make([]float64, 10000)
+	for i := range weights {
+		weights[i] = float64(i % 10)
+	}
+	w, err := NewWeighted(weights)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	var counts [10]int
+	for i := 0; i < 1e6; i++ {
+		j := w.Next(r)
+		if j%10 == 0 {
+			t.Fatalf("sampled zero-weight index %d", j)
+		}
+		counts[j%10]++
+	}
+	probs := make([]float64, len(counts))
+	for i := range probs {
+		probs[i] = float64(i) / 45
+	}
+	checkChiSquare(t, counts[:], probs)
+}
+
+func TestWeightedInvalid(t *testing.T) {
+	for _, weights := range [][]float64{
+		nil,
+		{},
+		{0},
+		{0, 0, 0},
+		{1, -1},
+		{1, math.NaN()},
+		{1, math.Inf(1)},
+		{math.MaxFloat64, math.MaxFloat64},
+	} {
+		if _, err := NewWeighted(weights); err == nil {
+			t.Fatalf("%v: expected an error", weights)
+		}
+	}
+}
+
+func TestWeightedShuffle(t *testing.T) {
+	items := []string{"heavy", "mid", "light", "none"}
+	weights := []float64{6, 3, 1, 0}
+
+	var first [3]int
+	var rank [4]int
+	const runs = 50000
+	for i := 0; i < runs; i++ {
+		got := WeightedShuffle(items, weights)
+		if len(got) != len(items) {
+			t.Fatalf("got %d items, expected %d", len(got), len(items))
+		}
+		if got[3] != "none" {
+			t.Fatalf("zero-weight item not last: %v", got)
+		}
+		for j, v := range got {
+			switch v {
+			case "heavy":
+				rank[0] += j
+				first[0] += b2i(j == 0)
+			case "mid":
+				rank[1] += j
+				first[1] += b2i(j == 0)
+			case "light":
+				rank[2] += j
+				first[2] += b2i(j == 0)
+			}
+		}
+	}
+	if !(rank[0] < rank[1] && rank[1] < rank[2]) {
+		t.Fatalf("heavier items should rank earlier: total ranks %v", rank[:3])
+	}
+	// The first item is chosen in proportion to its weight.
+	checkChiSquare(t, first[:], []float64{0.6, 0.3, 0.1})
+
+	// Zero-weight items are shuffled uniformly at the end.
+	var zeros [3]int
+	for i := 0; i < 30000; i++ {
+		got := WeightedShuffle([]int{0, 1, 2, 3}, []float64{1, 0, 0, 0})
+		if got[0] != 0 {
+			t.Fatalf("weighted item not first: %v", got)
+		}
+		zeros[got[1]-1]++
+	}
+	checkUniform(t, zeros[:])
+
+	if got := WeightedShuffle([]int{}, nil); len(got) != 0 {
+		t.Fatalf("empty: got %v", got)
+	}
+
+	checkPanics(t, "mismatched lengths", func() {
+		WeightedShuffle([]int{1, 2}, []float64{1})
+	})
+	for _, w := range []float64{-1, math.NaN(), math.Inf(1)} {
+		checkPanics(t, "invalid weight", func() {
+			WeightedShuffle([]int{1, 2}, []float64{1, w})
+		})
+	}
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestCategorical(t *testing.T) {
+	labels := []string{"red", "green", "blue", "never"}
+	probs := []float64{0.5, 0.3, 0.2, 0}
+	c, err := NewCategorical(labels, probs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != len(labels) {
+		t.Fatalf("Len: expected %d, got %d", len(labels), c.Len())
+	}
+	// Modifying the input does not affect c.
+	labels[0] = "changed"
+
+	index := map[string]int{"red": 0, "green": 1, "blue": 2, "never": 3}
+	r := New()
+	counts := make([]int, len(probs))
+	for i := 0; i < 2e5; i++ {
+		v := c.Sample(r)
+		j, ok := index[v]
+		if !ok {
+			t.Fatalf("unexpected label %q", v)
+		}
+		counts[j]++
+	}
+	checkChiSquare(t, counts, probs)
+}
+
+func TestCategoricalInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		labels []int
+		probs  []float64
+		err    error
+	}{
+		{[]int{1}, []float64{0.5, 0.5}, errLabelMismatch},
+		{nil, nil, errNoWeights},
+		{[]int{1, 2}, []float64{-0.5, 1.5}, errInvalidWeight},
+		{[]int{1, 2}, []float64{math.NaN(), 1}, errInvalidWeight},
+		{[]int{1, 2}, []float64{0, 0}, errZeroTotalWeight},
+		{[]int{1, 2}, []float64{0.5, 0.6}, errProbSum},
+		{[]int{1, 2}, []float64{1, 1}, errProbSum},
+	} {
+		if _, err := NewCategorical(tc.labels, tc.probs); err != tc.err {
+			t.Errorf("%v, %v: expected %v, got %v", tc.labels, tc.probs, tc.err, err)
+		}
+	}
+
+	// Small rounding error is allowed.
+	if _, err := NewCategorical([]int{1, 2, 3}, []float64{0.1, 0.2, 0.7}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestMixture(t *testing.T) {
+	m, err := NewMixture([]func(*Rand) float64{
+		func(r *Rand) float64 { return r.Normal(-5, 1) },
+		func(r *Rand) float64 { return r.Normal(5, 1) },
+	}, []float64{3, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Len() != 2 {
+		t.Fatalf("Len: expected 2, got %d", m.Len())
+	}
+
+	const n = 100000
+	var (
+		sides [2]int
+		sums  [2]float64
+		hist  [6]int // [-6, -4), [-4, -2), ..., [4, 6)
+		r     = New()
+	)
+	for i := 0; i < n; i++ {
+		x := m.Sample(r)
+		side := b2i(x >= 0)
+		sides[side]++
+		sums[side] += x
+		if j := int(math.Floor((x + 6) / 2)); j >= 0 && j < len(hist) {
+			hist[j]++
+		}
+	}
+	// The components are 10 standard deviations apart, so the
+	// sign of a sample identifies its component.
+	checkChiSquare(t, sides[:], []float64{0.3, 0.7})
+	for i, want := range []float64{-5, 5} {
+		// Allow about 5 standard errors.
+		if mean := sums[i] / float64(sides[i]); math.Abs(mean-want) > 0.05 {
+			t.Errorf("component %d: mean %g, expected %g", i, mean, want)
+		}
+	}
+	// Bimodal: both peaks dwarf the trough at zero.
+	if trough := hist[2] + hist[3]; trough*10 > hist[0] || trough*10 > hist[5] {
+		t.Fatalf("not bimodal: %v", hist)
+	}
+
+	comps := []func(*Rand) float64{
+		func(*Rand) float64 { return 1 },
+		func(*Rand) float64 { return 2 },
+	}
+	for _, tc := range []struct {
+		components []func(*Rand) float64
+		weights    []float64
+		err        error
+	}{
+		{comps, []float64{1}, errMixtureMismatch},
+		{nil, nil, errNoWeights},
+		{[]func(*Rand) float64{comps[0], nil}, []float64{1, 1}, errNilComponent},
+		{comps, []float64{1, -1}, errInvalidWeight},
+		{comps, []float64{1, math.NaN()}, errInvalidWeight},
+		{comps, []float64{0, 0}, errZeroTotalWeight},
+	} {
+		if _, err := NewMixture(tc.components, tc.weights); err != tc.err {
+			t.Errorf("%v: expected %v, got %v", tc.weights, tc.err, err)
+		}
+	}
+}
+
+func TestChoiceCounts(t *testing.T) {
+	for _, counts := range [][]int{
+		{1},
+		{3, 1},
+		{0, 5, 0, 1, 2},
+		{1, 0, 0, 0, 0},
+	} {
+		var total int
+		for _, c := range counts {
+			total += c
+		}
+		probs := make([]float64, len(counts))
+		for i, c := range counts {
+			probs[i] = float64(c) / float64(total)
+		}
+		got := make([]int, len(counts))
+		for i := 0; i < 50000; i++ {
+			got[ChoiceCounts(counts)]++
+		}
+		// checkChiSquare fails if a zero-count bucket is
+		// ever chosen.
+		checkChiSquare(t, got, probs)
+	}
+
+	// Large counts use exact arithmetic.
+	r := NewWithSource(&seqSource{vals: []uint64{math.MaxInt - 1, math.MaxInt}})
+	big := []int{math.MaxInt, 1}
+	if i := r.ChoiceCounts(big); i != 0 {
+		t.Fatalf("expected 0, got %d", i)
+	}
+	if i := r.ChoiceCounts(big); i != 1 {
+		t.Fatalf("expected 1, got %d", i)
+	}
+
+	for _, counts := range [][]int{
+		nil,
+		{0, 0},
+		{1, -1},
+	} {
+		checkPanics(t, "ChoiceCounts", func() { ChoiceCounts(counts) })
+	}
+	if math.MaxInt == math.MaxInt64 {
+		overflow := []int{math.MaxInt, math.MaxInt, math.MaxInt}
+		checkPanics(t, "ChoiceCounts(overflow)", func() { ChoiceCounts(overflow) })
+	}
+}
+
+func TestSampleLogits(t *testing.T) {
+	logits := []float64{1, 2, 0.5, math.Inf(-1), 3}
+	for _, temp := range []float64{0.25, 1, 4} {
+		probs := make([]float64, len(logits))
+		var total float64
+		for i, x := range logits {
+			probs[i] = math.Exp(x / temp)
+			total += probs[i]
+		}
+		for i := range probs {
+			probs[i] /= total
+		}
+		counts := make([]int, len(logits))
+		for i := 0; i < 100000; i++ {
+			counts[SampleLogits(logits, temp)]++
+		}
+		// checkChiSquare fails if the -Inf logit is ever
+		// chosen.
+		checkChiSquare(t, counts, probs)
+	}
+
+	// Higher temperatures flatten the distribution.
+	prev := 2.0
+	for _, temp := range []float64{0.1, 0.5, 1, 2, 10, 100} {
+		var top int
+		for i := 0; i < 20000; i++ {
+			if SampleLogits(logits, temp) == 4 {
+				top++
+			}
+		}
+		p := float64(top) / 20000
+		if p >= prev {
+			t.Fatalf("temperature %g: P(argmax) = %g, expected less than %g", temp, p, prev)
+		}
+		prev = p
+	}
+	if prev > 0.3 {
+		t.Fatalf("temperature 100: P(argmax) = %g, expected about 1/4", prev)
+	}
+
+	// Temperature 0 is argmax and reads no randomness.
+	r := NewWithSource(NewSourceFromReader(bytes.NewReader(nil)))
+	for i := 0; i < 10; i++ {
+		if got := r.SampleLogits(logits, 0); got != 4 {
+			t.Fatalf("temperature 0: got %d, expected 4", got)
+		}
+	}
+	if got := SampleLogits([]float64{5, 7, 7, 1}, 0); got != 1 {
+		t.Fatalf("temperature 0 tie: got %d, expected 1", got)
+	}
+
+	// Temperature +Inf is uniform over the finite logits.
+	counts := make([]int, len(logits))
+	for i := 0; i < 40000; i++ {
+		counts[SampleLogits(logits, math.Inf(1))]++
+	}
+	checkChiSquare(t, counts, []float64{0.25, 0.25, 0.25, 0, 0.25})
+
+	// Large logits do not overflow.
+	counts = make([]int, 2)
+	for i := 0; i < 20000; i++ {
+		counts[SampleLogits([]float64{1000, 1000}, 1)]++
+	}
+	checkUniform(t, counts)
+	if got := SampleLogits([]float64{math.Inf(-1), -1e308}, 1e-300); got != 1 {
+		t.Fatalf("tiny temperature: got %d, expected 1", got)
+	}
+
+	for _, tc := range []struct {
+		logits []float64
+		temp   float64
+	}{
+		{nil, 1},
+		{[]float64{1}, -1},
+		{[]float64{1}, math.NaN()},
+		{[]float64{1, math.NaN()}, 1},
+		{[]float64{1, math.Inf(1)}, 1},
+		{[]float64{math.Inf(-1), math.Inf(-1)}, 1},
+	} {
+		checkPanics(t, "SampleLogits", func() { SampleLogits(tc.logits, tc.temp) })
+	}
+}
@@ -0,0 +1,23 @@
+package saferand
+
+import (
+	"runtime"
+)
+
+// wipe zeroes b.
+//
+// It is used to clear random bytes from working buffers once
+// they are no longer needed, so that they do not linger in
+// memory that might be reused or inspected later.
+//
+// wipe is never inlined and keeps b alive until the stores are
+// complete, so the compiler cannot treat the stores as dead
+// and remove them, even if b is never read again.
+//
+//go:noinline
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	runtime.KeepAlive(b)
+}
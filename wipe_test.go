@@ -0,0 +1,53 @@
+package saferand
+
+import (
+	"testing"
+)
+
+func TestWipe(t *testing.T) {
+	b := make([]byte, 100)
+	for i := range b {
+		b[i] = byte(i + 1)
+	}
+	wipe(b[10:90])
+	for i, c := range b {
+		want := byte(0)
+		if i < 10 || i >= 90 {
+			want = byte(i + 1)
+		}
+		if c != want {
+			t.Fatalf("#%d: got %#x, expected %#x", i, c, want)
+		}
+	}
+	wipe(nil)
+}
+
+// TestSourcesZeroConsumed checks that each buffered Source
+// zeroes the bytes it has handed out.
+func TestSourcesZeroConsumed(t *testing.T) {
+	ctr, err := NewCTRDRBGSource(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chacha := NewChaChaSource()
+	health := NewHealthCheckedSource(NewSource()).(*healthSource)
+	for _, tc := range []struct {
+		name string
+		src  Source
+		buf  func() []byte
+	}{
+		{"ChaCha", chacha, func() []byte { return chacha.buf[:chacha.off] }},
+		{"CTRDRBG", ctr, func() []byte { return ctr.buf[:ctr.off] }},
+		{"Health", health, func() []byte { return health.buf[:health.off] }},
+	} {
+		for i := 0; i < 100; i++ {
+			tc.src.Uint64()
+			for j, c := range tc.buf() {
+				if c != 0 {
+					t.Fatalf("%s: #%d: consumed byte %d not zeroed: %#x",
+						tc.name, i, j, c)
+				}
+			}
+		}
+	}
+}